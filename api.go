@@ -0,0 +1,187 @@
+package main
+
+import (
+	"encoding/json"
+	"log/slog"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// pageJSON is the response body for pages requested as JSON:
+//
+//	{
+//	  "title": "Page title",
+//	  "html": "<h1>Page title</h1>\n<p>Rendered body only, without the layout</p>\n",
+//	  "meta": {"description": "...", "date": "2024-01-15"},
+//	  "wordCount": 7
+//	}
+//
+// meta holds the page's frontmatter and is omitted when there is none.
+type pageJSON struct {
+	Title     string                 `json:"title"`
+	HTML      string                 `json:"html"`
+	Meta      map[string]interface{} `json:"meta,omitempty"`
+	WordCount int                    `json:"wordCount"`
+}
+
+// apiError is the response body for failed API requests
+type apiError struct {
+	Error  string `json:"error"`
+	Status int    `json:"status"`
+}
+
+// writeAPIError writes a JSON error with the given status
+func writeAPIError(w http.ResponseWriter, status int, message string) {
+	data, _ := json.Marshal(apiError{Error: message, Status: status})
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	w.Write(data)
+}
+
+// handleAPIPage serves /api/page?path=docs/setup, the page at that path as
+// JSON, for clients that fetch content rather than whole HTML pages. Paths
+// resolve as they do in the browser, except that missing pages are a JSON 404
+// rather than 404.md or the index.md fallback.
+func (s *Server) handleAPIPage(w http.ResponseWriter, r *http.Request) {
+	urlPath := "/" + strings.Trim(r.URL.Query().Get("path"), "/")
+
+	server := s
+	if len(s.mounts) > 0 {
+		m, ok := s.matchMount(urlPath)
+		if !ok {
+			writeAPIError(w, http.StatusNotFound, "page not found")
+			return
+		}
+		server = m.server
+		urlPath = "/" + strings.TrimPrefix(strings.TrimPrefix(urlPath, server.urlPrefix), "/")
+	}
+	server.serveAPIPage(w, r, strings.TrimPrefix(urlPath, "/"))
+}
+
+// serveAPIPage writes the page at relPath, relative to the content directory
+// and without its .md extension, as JSON
+func (s *Server) serveAPIPage(w http.ResponseWriter, r *http.Request, relPath string) {
+	if err := s.validatePath(relPath); err != nil || isHiddenPath(relPath) {
+		writeAPIError(w, http.StatusBadRequest, "invalid path")
+		return
+	}
+
+	// foo is foo.md, or foo/index.md for a directory
+	var filePath string
+	candidates := []string{relPath + ".md", path.Join(relPath, s.indexFile)}
+	if relPath == "" {
+		candidates = []string{s.indexFile}
+	}
+	for _, candidate := range candidates {
+		candidatePath := filepath.Join(s.config.ContentDir, filepath.FromSlash(candidate))
+		if !s.isPathSafe(candidatePath) {
+			writeAPIError(w, http.StatusBadRequest, "invalid path")
+			return
+		}
+		if info, err := os.Stat(candidatePath); err == nil && !info.IsDir() {
+			filePath = candidatePath
+			break
+		}
+	}
+	if filePath == "" {
+		writeAPIError(w, http.StatusNotFound, "page not found")
+		return
+	}
+
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		writeAPIError(w, http.StatusInternalServerError, "error reading page")
+		return
+	}
+	meta, body, err := parseFrontmatter(content)
+	if err != nil {
+		slog.Warn("invalid frontmatter", "path", filePath, "err", err)
+	}
+	if !s.isPublic(meta, s.contentRelPath(filePath)) {
+		writeAPIError(w, http.StatusNotFound, "page not found")
+		return
+	}
+
+	title := s.titleForFile(meta, body, filePath)
+	status := pageStatus(meta, filePath, http.StatusOK)
+	if status < http.StatusBadRequest {
+		w.Header().Set("Cache-Control", s.config.CacheControl)
+	}
+	writePageJSON(w, status, pageJSON{
+		Title:     title,
+		HTML:      s.markdownToHTML(body, title, filePath).HTML,
+		Meta:      meta,
+		WordCount: wordCount(body),
+	})
+}
+
+// wantsJSON reports whether the client asked for JSON, either with ?format=json
+// or an Accept header listing application/json
+func wantsJSON(r *http.Request) bool {
+	if r.URL.Query().Get("format") == "json" {
+		return true
+	}
+	for _, accept := range strings.Split(r.Header.Get("Accept"), ",") {
+		if mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(accept)); err == nil && mediaType == "application/json" {
+			return true
+		}
+	}
+	return false
+}
+
+// wantsText reports whether the client asked for the page as plain text with
+// ?format=txt
+func wantsText(r *http.Request) bool {
+	return r.URL.Query().Get("format") == "txt"
+}
+
+// wantsMarkdown reports whether the client asked for the page's markdown
+// source, either with ?raw=1 or an Accept header listing text/markdown
+func wantsMarkdown(r *http.Request) bool {
+	if raw := r.URL.Query().Get("raw"); raw == "1" || raw == "true" {
+		return true
+	}
+	for _, accept := range strings.Split(r.Header.Get("Accept"), ",") {
+		if mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(accept)); err == nil && mediaType == "text/markdown" {
+			return true
+		}
+	}
+	return false
+}
+
+// writeText writes a text version of a page, such as its markdown source, with
+// the given status and content type
+func (s *Server) writeText(w http.ResponseWriter, r *http.Request, status int, contentType string, source []byte, modTime time.Time) {
+	w.Header().Set("Content-Type", contentType)
+	if status < http.StatusBadRequest {
+		w.Header().Set("Cache-Control", s.config.CacheControl)
+	}
+	if status == http.StatusOK && setLastModified(w, r, modTime) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("Content-Length", strconv.Itoa(len(source)))
+	w.WriteHeader(status)
+	if r.Method != http.MethodHead {
+		w.Write(source)
+	}
+}
+
+// writePageJSON writes a rendered page as JSON with the given status
+func writePageJSON(w http.ResponseWriter, status int, page pageJSON) {
+	data, err := json.Marshal(page)
+	if err != nil {
+		slog.Error("failed to encode page as JSON", "err", err)
+		http.Error(w, "Error encoding page", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	w.Write(data)
+}
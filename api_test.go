@@ -0,0 +1,224 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestWantsJSON(t *testing.T) {
+	tests := []struct {
+		name   string
+		target string
+		accept string
+		want   bool
+	}{
+		{"browser", "/", "text/html,application/xhtml+xml,*/*;q=0.8", false},
+		{"no accept", "/", "", false},
+		{"accept json", "/", "application/json", true},
+		{"accept json with parameters", "/", "text/html, application/json; charset=utf-8", true},
+		{"format query", "/?format=json", "text/html", true},
+		{"other format", "/?format=txt", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			if tt.accept != "" {
+				r.Header.Set("Accept", tt.accept)
+			}
+			if got := wantsJSON(r); got != tt.want {
+				t.Errorf("wantsJSON = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPageContentNegotiation(t *testing.T) {
+	s := newTestServer(t, map[string]string{
+		"guide.md": "---\ntitle: Guide\ndescription: How to start\n---\n\nSome text\n",
+	}, nil)
+
+	tests := []struct {
+		name   string
+		target string
+		accept string
+		json   bool
+	}{
+		{"html", "/guide", "text/html", false},
+		{"accept header", "/guide", "application/json", true},
+		{"format query", "/guide?format=json", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			if tt.accept != "" {
+				r.Header.Set("Accept", tt.accept)
+			}
+			w := serve(s, r)
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, want 200", w.Code)
+			}
+			if vary := w.Header().Values("Vary"); !strings.Contains(strings.Join(vary, ","), "Accept") {
+				t.Errorf("Vary = %v, want Accept", vary)
+			}
+			contentType := w.Header().Get("Content-Type")
+
+			if !tt.json {
+				if !strings.HasPrefix(contentType, "text/html") {
+					t.Errorf("Content-Type = %q, want HTML", contentType)
+				}
+				if !strings.Contains(w.Body.String(), "<title>Guide") {
+					t.Errorf("body is not the full page:\n%s", w.Body.String())
+				}
+				return
+			}
+
+			if contentType != "application/json; charset=utf-8" {
+				t.Errorf("Content-Type = %q, want JSON", contentType)
+			}
+			var page pageJSON
+			if err := json.Unmarshal(w.Body.Bytes(), &page); err != nil {
+				t.Fatalf("invalid JSON: %v", err)
+			}
+			if page.Title != "Guide" {
+				t.Errorf("title = %q, want Guide", page.Title)
+			}
+			if page.Meta["description"] != "How to start" {
+				t.Errorf("meta = %v, want the frontmatter", page.Meta)
+			}
+			if strings.Contains(page.HTML, "<html") || strings.Contains(page.HTML, "<title>") {
+				t.Errorf("html includes the layout: %q", page.HTML)
+			}
+		})
+	}
+}
+
+func TestRawMarkdown(t *testing.T) {
+	source := "---\ntitle: Guide\n---\n\n# Guide\n\nSome *text*.\n"
+	s := newTestServer(t, map[string]string{"guide.md": source, "_draft.md": "# Draft\n"}, nil)
+
+	tests := []struct {
+		name   string
+		target string
+		accept string
+		status int
+		want   string
+	}{
+		{"accept header", "/guide", "text/markdown", http.StatusOK, source},
+		{"raw query", "/guide?raw=1", "", http.StatusOK, source},
+		{"raw true", "/guide?raw=true", "", http.StatusOK, source},
+		{"without frontmatter", "/guide?raw=1&frontmatter=0", "", http.StatusOK, "\n# Guide\n\nSome *text*.\n"},
+		{"draft", "/_draft?raw=1", "", http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			if tt.accept != "" {
+				r.Header.Set("Accept", tt.accept)
+			}
+			w := serve(s, r)
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d", w.Code, tt.status)
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+			if got := w.Header().Get("Content-Type"); got != "text/markdown; charset=utf-8" {
+				t.Errorf("Content-Type = %q", got)
+			}
+			if got := w.Body.String(); got != tt.want {
+				t.Errorf("body = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAPIPage(t *testing.T) {
+	s := newTestServer(t, map[string]string{
+		"index.md":        "---\ntitle: Home\n---\n",
+		"docs/setup.md":   "---\ntitle: Setup\ntags: [install, config]\n---\n\nSome text\n",
+		"docs/index.md":   "---\ntitle: Docs\n---\n",
+		"docs/gone.md":    "---\ntitle: Gone\nstatus: 410\n---\n",
+		"docs/_draft.md":  "---\ntitle: Draft\n---\n",
+		"docs/.hidden.md": "---\ntitle: Hidden\n---\n",
+	}, nil)
+
+	tests := []struct {
+		name   string
+		path   string
+		status int
+		title  string
+	}{
+		{"page", "docs/setup", http.StatusOK, "Setup"},
+		{"leading slash", "/docs/setup", http.StatusOK, "Setup"},
+		{"directory", "docs", http.StatusOK, "Docs"},
+		{"root", "", http.StatusOK, "Home"},
+		{"page status", "docs/gone", http.StatusGone, "Gone"},
+		{"missing", "docs/missing", http.StatusNotFound, ""},
+		{"draft", "docs/_draft", http.StatusNotFound, ""},
+		{"hidden", "docs/.hidden", http.StatusBadRequest, ""},
+		{"traversal", "../etc/passwd", http.StatusBadRequest, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := get(s, "/api/page?path="+url.QueryEscape(tt.path))
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.status, w.Body.String())
+			}
+			if got := w.Header().Get("Content-Type"); got != "application/json; charset=utf-8" {
+				t.Errorf("Content-Type = %q", got)
+			}
+
+			if tt.title == "" {
+				var apiErr apiError
+				if err := json.Unmarshal(w.Body.Bytes(), &apiErr); err != nil {
+					t.Fatalf("invalid JSON error: %v", err)
+				}
+				if apiErr.Status != tt.status || apiErr.Error == "" {
+					t.Errorf("error = %+v, want status %d and a message", apiErr, tt.status)
+				}
+				return
+			}
+
+			var page pageJSON
+			if err := json.Unmarshal(w.Body.Bytes(), &page); err != nil {
+				t.Fatalf("invalid JSON: %v", err)
+			}
+			if page.Title != tt.title {
+				t.Errorf("title = %q, want %q", page.Title, tt.title)
+			}
+		})
+	}
+}
+
+func TestAPIPageMeta(t *testing.T) {
+	s := newTestServer(t, map[string]string{
+		"setup.md": "---\ntitle: Setup\ntags: [install, config]\n---\n\nSome text\n",
+		"plain.md": "Some text\n",
+	}, nil)
+
+	var page map[string]interface{}
+	if err := json.Unmarshal(get(s, "/api/page?path=setup").Body.Bytes(), &page); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"title", "html", "meta", "wordCount"} {
+		if _, ok := page[key]; !ok {
+			t.Errorf("response has no %s", key)
+		}
+	}
+	meta, _ := page["meta"].(map[string]interface{})
+	if tags, _ := json.Marshal(meta["tags"]); string(tags) != `["install","config"]` {
+		t.Errorf("meta.tags = %s, want the frontmatter list", tags)
+	}
+
+	page = nil
+	if err := json.Unmarshal(get(s, "/api/page?path=plain").Body.Bytes(), &page); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := page["meta"]; ok {
+		t.Error("meta present for a page without frontmatter")
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"io/fs"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// assetsPrefix is the URL prefix ASSETS_DIR is served under
+const assetsPrefix = "/assets/"
+
+// staticTypes are the content types of the asset extensions commonly embedded
+// in pages. Requests with these extensions are always answered from the
+// content directory, and never fall back to a markdown page when the file is
+// missing. They are also used when the system has no MIME type for the
+// extension, as in the scratch container, which has no /etc/mime.types.
+var staticTypes = map[string]string{
+	".png":   "image/png",
+	".jpg":   "image/jpeg",
+	".jpeg":  "image/jpeg",
+	".gif":   "image/gif",
+	".svg":   "image/svg+xml",
+	".webp":  "image/webp",
+	".avif":  "image/avif",
+	".ico":   "image/x-icon",
+	".woff":  "font/woff",
+	".woff2": "font/woff2",
+	".ttf":   "font/ttf",
+	".otf":   "font/otf",
+	".css":   "text/css; charset=utf-8",
+	".js":    "text/javascript; charset=utf-8",
+	".json":  "application/json",
+	".pdf":   "application/pdf",
+	".mp4":   "video/mp4",
+	".webm":  "video/webm",
+}
+
+// isStaticAsset reports whether ext is a known asset extension
+func isStaticAsset(ext string) bool {
+	_, ok := staticTypes[strings.ToLower(ext)]
+	return ok
+}
+
+// assetContentType returns the content type for a file extension, or "" to
+// let http.ServeFile sniff it
+func assetContentType(ext string) string {
+	if contentType := mime.TypeByExtension(ext); contentType != "" {
+		return contentType
+	}
+	return staticTypes[strings.ToLower(ext)]
+}
+
+// assetFS is ASSETS_DIR as served by http.FileServer. http.Dir already keeps
+// requests inside the directory; assetFS also hides dotfiles and refuses to
+// open directories, so there are no listings.
+type assetFS struct {
+	dir http.Dir
+}
+
+func (fsys assetFS) Open(name string) (http.File, error) {
+	if isHiddenPath(strings.TrimPrefix(path.Clean("/"+name), "/")) {
+		return nil, fs.ErrNotExist
+	}
+	f, err := fsys.dir.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	if info.IsDir() {
+		f.Close()
+		return nil, fs.ErrNotExist
+	}
+	return f, nil
+}
+
+// handleAssets serves /assets/ from ASSETS_DIR, independently of the content
+// directory
+func (s *Server) handleAssets() http.HandlerFunc {
+	fsys := assetFS{http.Dir(s.config.AssetsDir)}
+	files := http.StripPrefix(strings.TrimSuffix(assetsPrefix, "/"), http.FileServer(fsys))
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Only existing files are cacheable; a CDN mustn't keep the 404 of
+		// an asset that is deployed later
+		name := "/" + strings.TrimPrefix(r.URL.Path, assetsPrefix)
+		if f, err := fsys.Open(name); err == nil {
+			f.Close()
+			if contentType := assetContentType(path.Ext(r.URL.Path)); contentType != "" {
+				w.Header().Set("Content-Type", contentType)
+			}
+			w.Header().Set("Cache-Control", s.config.assetCacheControl())
+			if servePrecompressed(w, r, fsys.Open, name) {
+				return
+			}
+		}
+		files.ServeHTTP(w, r)
+	}
+}
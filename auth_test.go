@@ -0,0 +1,132 @@
+package main
+
+import (
+	"crypto/sha1"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// authGet requests target with the given basic auth credentials, or none when
+// user is empty
+func authGet(s *Server, target, user, pass string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodGet, target, nil)
+	if user != "" {
+		r.SetBasicAuth(user, pass)
+	}
+	return serve(s, r)
+}
+
+func TestBasicAuth(t *testing.T) {
+	files := map[string]string{"index.md": "# Home\n", "staging/page.md": "# Staging\n", "public.md": "# Public\n", "staging-notes.md": "# Notes\n"}
+	tests := []struct {
+		name       string
+		configure  func(*Config)
+		target     string
+		user, pass string
+		status     int
+	}{
+		{"no credentials configured", nil, "/public", "", "", http.StatusOK},
+		{"missing credentials", siteAuth, "/public", "", "", http.StatusUnauthorized},
+		{"wrong password", siteAuth, "/public", "admin", "wrong", http.StatusUnauthorized},
+		{"wrong user", siteAuth, "/public", "root", "secret", http.StatusUnauthorized},
+		{"correct credentials", siteAuth, "/public", "admin", "secret", http.StatusOK},
+		{"probe exempt", siteAuth, healthzPath, "", "", http.StatusOK},
+		{"configured exemption", func(c *Config) {
+			siteAuth(c)
+			c.BasicAuthExempt = []string{"/public"}
+		}, "/public", "", "", http.StatusOK},
+		{"protected prefix", prefixAuth, "/staging/page", "", "", http.StatusUnauthorized},
+		{"protected prefix itself", prefixAuth, "/staging", "", "", http.StatusUnauthorized},
+		{"outside the prefix", prefixAuth, "/public", "", "", http.StatusOK},
+		{"prefix is not a string prefix", prefixAuth, "/staging-notes", "", "", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServer(t, files, tt.configure)
+			w := authGet(s, tt.target, tt.user, tt.pass)
+			if w.Code != tt.status {
+				t.Errorf("status = %d, want %d", w.Code, tt.status)
+			}
+			challenge := w.Header().Get("WWW-Authenticate")
+			if (tt.status == http.StatusUnauthorized) != strings.HasPrefix(challenge, "Basic realm=") {
+				t.Errorf("WWW-Authenticate = %q with status %d", challenge, w.Code)
+			}
+		})
+	}
+}
+
+// siteAuth protects the whole site with admin/secret
+func siteAuth(c *Config) {
+	c.BasicAuthUser, c.BasicAuthPass = "admin", "secret"
+}
+
+// prefixAuth protects /staging with admin/secret
+func prefixAuth(c *Config) {
+	siteAuth(c)
+	c.BasicAuthPaths = []string{"/staging/"}
+}
+
+func TestHtpasswdAuth(t *testing.T) {
+	bcryptHash, err := bcrypt.GenerateFromPassword([]byte("bcrypt-pass"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum := sha1.Sum([]byte("sha-pass"))
+	file := filepath.Join(t.TempDir(), ".htpasswd")
+	content := "# users\nalice:" + string(bcryptHash) + "\n\nbob:{SHA}" + base64.StdEncoding.EncodeToString(sum[:]) + "\n"
+	if err := os.WriteFile(file, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	s := newTestServer(t, map[string]string{"index.md": "# Home\n"}, func(c *Config) { c.BasicAuthFile = file })
+
+	tests := []struct {
+		user, pass string
+		status     int
+	}{
+		{"alice", "bcrypt-pass", http.StatusOK},
+		{"alice", "wrong", http.StatusUnauthorized},
+		{"bob", "sha-pass", http.StatusOK},
+		{"bob", "bcrypt-pass", http.StatusUnauthorized},
+		{"carol", "bcrypt-pass", http.StatusUnauthorized},
+		{"", "", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.user+":"+tt.pass, func(t *testing.T) {
+			if w := authGet(s, "/", tt.user, tt.pass); w.Code != tt.status {
+				t.Errorf("status = %d, want %d", w.Code, tt.status)
+			}
+		})
+	}
+}
+
+func TestLoadHtpasswd(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		ok      bool
+	}{
+		{"sha", "bob:{SHA}W6ph5Mm5Pz8GgiULbPgzG37mj9g=\n", true},
+		{"bcrypt", "alice:$2y$05$abcdefghijklmnopqrstuu5yFbkVZ0KxwfPAAr1dVYlP1C6Hlrq6q\n", true},
+		{"md5 rejected", "carol:$apr1$salt$hash\n", false},
+		{"no colon", "dave\n", false},
+		{"no users", "# nobody\n", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), ".htpasswd")
+			if err := os.WriteFile(file, []byte(tt.content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := loadHtpasswd(file); (err == nil) != tt.ok {
+				t.Errorf("loadHtpasswd = %v, want ok %v", err, tt.ok)
+			}
+		})
+	}
+}
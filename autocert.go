@@ -0,0 +1,37 @@
+package main
+
+import (
+	"log/slog"
+	"net"
+	"net/http"
+
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// defaultAutocertCacheDir stores certificates obtained for AUTOCERT_DOMAINS so
+// they survive restarts without hitting Let's Encrypt's rate limits
+const defaultAutocertCacheDir = "./autocert-cache"
+
+// autocertListeners serve handler over HTTPS with certificates obtained and
+// renewed automatically from Let's Encrypt for AUTOCERT_DOMAINS. Port 80
+// answers the HTTP-01 challenges and redirects all other requests to HTTPS.
+func (s *Server) autocertListeners(handler http.Handler) []listener {
+	manager := &autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		HostPolicy: autocert.HostWhitelist(s.config.AutocertDomains...),
+		Cache:      autocert.DirCache(s.config.AutocertCacheDir),
+		Email:      s.config.AutocertEmail,
+	}
+
+	httpServer := s.newHTTPServer(net.JoinHostPort(s.config.BindAddr, "80"), manager.HTTPHandler(http.HandlerFunc(s.redirectToHTTPS)))
+	slog.Info("answering ACME challenges and redirecting HTTP to HTTPS", "addr", httpServer.Addr)
+
+	tlsServer := s.newHTTPServer(s.config.httpsAddr(), handler)
+	tlsServer.TLSConfig = manager.TLSConfig()
+	slog.Info("starting HTTPS server", "addr", tlsServer.Addr, "domains", s.config.AutocertDomains, "content_dir", s.config.ContentDir)
+
+	return []listener{
+		httpListener(httpServer, httpServer.ListenAndServe),
+		httpListener(tlsServer, func() error { return tlsServer.ListenAndServeTLS("", "") }),
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// breadcrumb is a link in the breadcrumb trail. The current page has no URL.
+type breadcrumb struct {
+	Label string
+	URL   string
+}
+
+// breadcrumbs returns the trail from the home page to the requested page, e.g.
+// Home > Guides > Advanced > Tuning for /guides/advanced/tuning. Labels are
+// the title of the page each crumb links to when there is one, otherwise the
+// title-cased path segment.
+func (s *Server) breadcrumbs(r *http.Request) []breadcrumb {
+	home := breadcrumb{Label: "Home", URL: s.urlPrefix + "/"}
+	segments := strings.FieldsFunc(r.URL.Path, func(c rune) bool { return c == '/' })
+	if len(segments) == 0 {
+		home.URL = ""
+		return []breadcrumb{home}
+	}
+
+	crumbs := []breadcrumb{home}
+	for i, segment := range segments {
+		relPath := strings.Join(segments[:i+1], "/")
+		last := i == len(segments)-1
+		isDir := !last || strings.HasSuffix(r.URL.Path, "/")
+
+		// Directories are titled by their index page, pages by their own file
+		pagePath := strings.TrimSuffix(relPath, ".md") + ".md"
+		if isDir {
+			pagePath = relPath + "/" + s.indexFile
+		}
+		crumb := breadcrumb{Label: s.crumbLabel(pagePath, segment)}
+		if !last {
+			crumb.URL = s.urlPrefix + "/" + relPath + "/"
+		}
+		crumbs = append(crumbs, crumb)
+	}
+	return crumbs
+}
+
+// crumbLabel returns the title of the page at relPath, or segment title-cased
+// if there is no such page
+func (s *Server) crumbLabel(relPath, segment string) string {
+	path := filepath.Join(s.config.ContentDir, filepath.FromSlash(relPath))
+	if s.isPathSafe(path) {
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return s.fileTitle(path)
+		}
+	}
+	return s.titleFromFilename(strings.TrimSuffix(segment, ".md"))
+}
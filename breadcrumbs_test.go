@@ -0,0 +1,42 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestBreadcrumbs(t *testing.T) {
+	s := newTestServer(t, map[string]string{
+		"index.md":                  "# Home\n",
+		"guides/index.md":           "---\ntitle: All Guides\n---\n",
+		"guides/advanced/tuning.md": "---\ntitle: Performance Tuning\n---\n",
+		"about.md":                  "---\ntitle: About Us\n---\n",
+	}, nil)
+
+	tests := []struct {
+		name string
+		path string
+		want []breadcrumb
+	}{
+		{"root", "/", []breadcrumb{{"Home", ""}}},
+		{"single level", "/about", []breadcrumb{{"Home", "/"}, {"About Us", ""}}},
+		{"nested", "/guides/advanced/tuning", []breadcrumb{
+			{"Home", "/"},
+			{"All Guides", "/guides/"},
+			{"Advanced", "/guides/advanced/"},
+			{"Performance Tuning", ""},
+		}},
+		{"directory", "/guides/", []breadcrumb{{"Home", "/"}, {"All Guides", ""}}},
+		{"missing page", "/release-notes", []breadcrumb{{"Home", "/"}, {"Release Notes", ""}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if got := s.breadcrumbs(r); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("breadcrumbs(%s) = %+v, want %+v", tt.path, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// assetCacheControl returns the Cache-Control value for static assets such as
+// images, which can be cached longer than pages with ASSET_MAX_AGE
+func (c Config) assetCacheControl() string {
+	if c.AssetMaxAge > 0 {
+		return fmt.Sprintf("public, max-age=%d", c.AssetMaxAge)
+	}
+	return c.CacheControl
+}
+
+// contentETag returns a strong entity tag for a response body
+func contentETag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"` + hex.EncodeToString(sum[:8]) + `"`
+}
+
+// setLastModified sets the Last-Modified header and reports whether the client's
+// cached copy is still fresh, in which case the caller should respond with 304.
+func setLastModified(w http.ResponseWriter, r *http.Request, modTime time.Time) bool {
+	modTime = modTime.UTC().Truncate(time.Second)
+	w.Header().Set("Last-Modified", modTime.Format(http.TimeFormat))
+	return notModified(w, r, modTime)
+}
+
+// notModified reports whether the client's cached copy, identified by the
+// response's ETag or its modification time, is still fresh. As in
+// http.ServeContent, If-None-Match takes precedence over If-Modified-Since
+// when the response has an ETag. A zero modTime only compares ETags.
+func notModified(w http.ResponseWriter, r *http.Request, modTime time.Time) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		etag := w.Header().Get("ETag")
+		if etag == "" {
+			return false
+		}
+		for _, candidate := range strings.Split(inm, ",") {
+			candidate = strings.TrimSpace(candidate)
+			if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+				return true
+			}
+		}
+		return false
+	}
+
+	if modTime.IsZero() {
+		return false
+	}
+	since, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+	return err == nil && !modTime.After(since)
+}
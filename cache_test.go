@@ -0,0 +1,129 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCacheControl(t *testing.T) {
+	tests := []struct {
+		name      string
+		configure func(*Config)
+		path      string
+		want      string
+	}{
+		{"default page", nil, "/", "no-cache"},
+		{"configured page", func(c *Config) { c.CacheControl = "public, max-age=300" }, "/", "public, max-age=300"},
+		{"page ignores asset max age", func(c *Config) { c.AssetMaxAge = 86400 }, "/", "no-cache"},
+		{"asset follows pages by default", func(c *Config) { c.CacheControl = "private" }, "/logo.png", "private"},
+		{"asset max age", func(c *Config) { c.AssetMaxAge = 86400 }, "/logo.png", "public, max-age=86400"},
+	}
+	files := map[string]string{"index.md": "# Home\n", "logo.png": "\x89PNG\r\n\x1a\n"}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServer(t, files, tt.configure)
+			w := get(s, tt.path)
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, want 200", w.Code)
+			}
+			if got := w.Header().Get("Cache-Control"); got != tt.want {
+				t.Errorf("Cache-Control = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCacheControlNotFound(t *testing.T) {
+	tests := []struct {
+		name   string
+		files  map[string]string
+		target string
+	}{
+		{"missing page", map[string]string{"page.md": "# Page\n"}, "/missing"},
+		{"custom 404 page", map[string]string{"404.md": "# Not Found\n"}, "/missing"},
+		{"missing stylesheet", map[string]string{"docs/page.md": "# Page\n"}, "/docs/style.css"},
+		{"missing asset", map[string]string{"page.md": "# Page\n"}, "/missing.png"},
+		{"missing file in assets dir", map[string]string{"page.md": "# Page\n"}, "/assets/missing.png"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assets := t.TempDir()
+			s := newTestServer(t, tt.files, func(c *Config) {
+				// Cacheable everywhere, so a leak onto the 404 shows
+				c.CacheControl = "public, max-age=300"
+				c.AssetMaxAge = 86400
+				c.AssetsDir = assets
+			})
+			w := get(s, tt.target)
+			if w.Code != http.StatusNotFound {
+				t.Fatalf("status = %d, want 404", w.Code)
+			}
+			if got := w.Header().Get("Cache-Control"); got != "" {
+				t.Errorf("Cache-Control = %q on a 404, want none", got)
+			}
+		})
+	}
+}
+
+func TestCacheMaxAgeEnv(t *testing.T) {
+	tests := []struct {
+		name         string
+		cacheControl string
+		maxAge       string
+		want         string
+	}{
+		{"default", "", "", "no-cache"},
+		{"max age", "", "600", "public, max-age=600"},
+		{"cache control", "private", "", "private"},
+		{"explicit cache control wins", "private", "600", "private"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("CACHE_CONTROL", tt.cacheControl)
+			t.Setenv("CACHE_MAX_AGE", tt.maxAge)
+			config, err := loadConfig(nil)
+			if err != nil {
+				t.Fatalf("loadConfig: %v", err)
+			}
+			if config.CacheControl != tt.want {
+				t.Errorf("CacheControl = %q, want %q", config.CacheControl, tt.want)
+			}
+		})
+	}
+}
+
+func TestNotModified(t *testing.T) {
+	modTime := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		etag   string
+		header map[string]string
+		want   bool
+	}{
+		{"no validators", `"abc"`, nil, false},
+		{"matching etag", `"abc"`, map[string]string{"If-None-Match": `"abc"`}, true},
+		{"weak etag", `"abc"`, map[string]string{"If-None-Match": `W/"abc"`}, true},
+		{"etag list", `"abc"`, map[string]string{"If-None-Match": `"xyz", "abc"`}, true},
+		{"other etag", `"abc"`, map[string]string{"If-None-Match": `"xyz"`}, false},
+		{"etag takes precedence", `"abc"`, map[string]string{"If-None-Match": `"xyz"`, "If-Modified-Since": modTime.Format(http.TimeFormat)}, false},
+		{"unchanged since", "", map[string]string{"If-Modified-Since": modTime.Format(http.TimeFormat)}, true},
+		{"changed since", "", map[string]string{"If-Modified-Since": modTime.Add(-time.Hour).Format(http.TimeFormat)}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			for key, value := range tt.header {
+				r.Header.Set(key, value)
+			}
+			w := httptest.NewRecorder()
+			if tt.etag != "" {
+				w.Header().Set("ETag", tt.etag)
+			}
+			if got := notModified(w, r, modTime); got != tt.want {
+				t.Errorf("notModified = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Canonical URL policies (CANONICAL_URLS)
+const (
+	canonicalStrip = "strip" // pages at /page, directories at /dir/
+	canonicalSlash = "slash" // pages at /page/, directories at /dir/
+)
+
+// canonicalPath returns the canonical form of a request for a page or
+// directory under CANONICAL_URLS: without the .md extension, with index pages
+// at their directory's URL, directories ending in a slash and pages ending in
+// one only with the slash policy. urlPath is the request path without its
+// leading slash. It reports false when the path is already canonical or isn't
+// a page or directory, so missing pages are left to the 404 handling.
+func (s *Server) canonicalPath(urlPath string) (string, bool) {
+	if urlPath == "" {
+		return "", false
+	}
+	indexName := strings.TrimSuffix(s.indexFile, ".md")
+
+	// A page named like a directory is the page, unless the request ends in a
+	// slash: /guide is guide.md and /guide/ is guide/index.md
+	name := strings.TrimSuffix(strings.TrimSuffix(urlPath, "/"), ".md")
+	var target string
+	isDir := false
+	switch {
+	case path.Base(name) == indexName:
+		target, isDir = path.Dir(name), true
+	case strings.HasSuffix(urlPath, ".md"):
+		target = name
+	case strings.HasSuffix(urlPath, "/") && s.isContentDir(name):
+		target, isDir = name, true
+	case s.isContentFile(name + ".md"):
+		target = name
+	case s.isContentDir(name):
+		target, isDir = name, true
+	default:
+		return "", false
+	}
+
+	canonical := "/" + target
+	switch {
+	case isDir && target == ".":
+		canonical = "/"
+	case isDir || s.config.CanonicalURLs == canonicalSlash:
+		canonical += "/"
+	}
+	if canonical == "/"+urlPath {
+		return "", false
+	}
+	return canonical, true
+}
+
+// canonicalize redirects a request for a page or directory to its canonical
+// URL, keeping the query string. It reports whether it responded.
+func (s *Server) canonicalize(w http.ResponseWriter, r *http.Request, urlPath string) bool {
+	if s.config.CanonicalURLs == "" {
+		return false
+	}
+	canonical, ok := s.canonicalPath(urlPath)
+	if !ok {
+		return false
+	}
+	target := s.urlPrefix + canonical
+	if r.URL.RawQuery != "" {
+		target += "?" + r.URL.RawQuery
+	}
+	http.Redirect(w, r, target, http.StatusMovedPermanently)
+	return true
+}
+
+// isContentDir reports whether relPath is a directory in the content directory
+func (s *Server) isContentDir(relPath string) bool {
+	dirPath := filepath.Join(s.config.ContentDir, filepath.FromSlash(relPath))
+	info, err := os.Stat(dirPath)
+	return err == nil && info.IsDir() && s.isPathSafe(dirPath)
+}
+
+// isContentFile reports whether relPath is a file in the content directory
+func (s *Server) isContentFile(relPath string) bool {
+	filePath := filepath.Join(s.config.ContentDir, filepath.FromSlash(relPath))
+	info, err := os.Stat(filePath)
+	return err == nil && !info.IsDir() && s.isPathSafe(filePath)
+}
@@ -0,0 +1,607 @@
+package main
+
+import (
+	"bytes"
+	"crypto/tls"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/alecthomas/chroma/v2/styles"
+	"gopkg.in/yaml.v3"
+)
+
+// Config holds the server configuration. Fields can be set in a YAML or TOML
+// config file using the key names in the struct tags.
+type Config struct {
+	ContentDir            string   `yaml:"content_dir" toml:"content_dir"`
+	DefaultDocument       string   `yaml:"default_document" toml:"default_document"`
+	AssetsDir             string   `yaml:"assets_dir" toml:"assets_dir"`
+	Port                  string   `yaml:"port" toml:"port"`
+	BindAddr              string   `yaml:"addr" toml:"addr"`
+	EnableSecurityHeaders bool     `yaml:"security_headers" toml:"security_headers"`
+	CSP                   string   `yaml:"csp" toml:"csp"`
+	CSPFrameAncestors     string   `yaml:"csp_frame_ancestors" toml:"csp_frame_ancestors"`
+	DisableCSP            bool     `yaml:"disable_csp" toml:"disable_csp"`
+	FramePolicy           string   `yaml:"frame_policy" toml:"frame_policy"`
+	CacheControl          string   `yaml:"cache_control" toml:"cache_control"`
+	CacheMaxAge           int      `yaml:"cache_max_age" toml:"cache_max_age"`
+	AssetMaxAge           int      `yaml:"asset_max_age" toml:"asset_max_age"`
+	SingleH1              string   `yaml:"single_h1" toml:"single_h1"`
+	CanonicalURLs         string   `yaml:"canonical_urls" toml:"canonical_urls"`
+	LiveReload            bool     `yaml:"live_reload" toml:"live_reload"`
+	TLSCertFile           string   `yaml:"tls_cert_file" toml:"tls_cert_file"`
+	TLSKeyFile            string   `yaml:"tls_key_file" toml:"tls_key_file"`
+	HTTP3                 bool     `yaml:"http3" toml:"http3"`
+	HTTPSPort             string   `yaml:"https_port" toml:"https_port"`
+	TLSRedirect           bool     `yaml:"tls_redirect" toml:"tls_redirect"`
+	AutocertDomains       []string `yaml:"autocert_domains" toml:"autocert_domains"`
+	AutocertCacheDir      string   `yaml:"autocert_cache_dir" toml:"autocert_cache_dir"`
+	AutocertEmail         string   `yaml:"autocert_email" toml:"autocert_email"`
+	FeedFormat            string   `yaml:"feed_format" toml:"feed_format"`
+	SearchIndex           string   `yaml:"search_index" toml:"search_index"`
+	SearchMaxTerms        int      `yaml:"search_max_terms" toml:"search_max_terms"`
+	PreviewMode           bool     `yaml:"preview_mode" toml:"preview_mode"`
+	HighlightTheme        string   `yaml:"highlight_theme" toml:"highlight_theme"`
+	DisableMath           bool     `yaml:"disable_math" toml:"disable_math"`
+	GenerateTOC           bool     `yaml:"generate_toc" toml:"generate_toc"`
+	HeadingAnchors        bool     `yaml:"heading_anchors" toml:"heading_anchors"`
+	EnableEmoji           bool     `yaml:"enable_emoji" toml:"enable_emoji"`
+	WikiLinks             bool     `yaml:"wiki_links" toml:"wiki_links"`
+	StructuredData        bool     `yaml:"structured_data" toml:"structured_data"`
+	Sidebar               bool     `yaml:"sidebar" toml:"sidebar"`
+	PageNavAcrossDirs     bool     `yaml:"page_nav_across_dirs" toml:"page_nav_across_dirs"`
+	BasicAuthUser         string   `yaml:"basic_auth_user" toml:"basic_auth_user"`
+	BasicAuthPass         string   `yaml:"basic_auth_pass" toml:"basic_auth_pass"`
+	BasicAuthExempt       []string `yaml:"basic_auth_exempt" toml:"basic_auth_exempt"`
+	BasicAuthFile         string   `yaml:"basic_auth_file" toml:"basic_auth_file"`
+	BasicAuthPaths        []string `yaml:"basic_auth_paths" toml:"basic_auth_paths"`
+	RateLimit             float64  `yaml:"rate_limit" toml:"rate_limit"`
+	RateLimitBurst        int      `yaml:"rate_limit_burst" toml:"rate_limit_burst"`
+	TrustProxy            bool     `yaml:"trust_proxy" toml:"trust_proxy"`
+	IPAllow               []string `yaml:"ip_allow" toml:"ip_allow"`
+	IPDeny                []string `yaml:"ip_deny" toml:"ip_deny"`
+	RobotsDisallow        []string `yaml:"robots_disallow" toml:"robots_disallow"`
+	SiteTitle             string   `yaml:"site_title" toml:"site_title"`
+	SiteDescription       string   `yaml:"site_description" toml:"site_description"`
+	SiteBaseURL           string   `yaml:"site_base_url" toml:"site_base_url"`
+	EditBaseURL           string   `yaml:"edit_base_url" toml:"edit_base_url"`
+	FeedLimit             int      `yaml:"feed_limit" toml:"feed_limit"`
+	ReadingWPM            int      `yaml:"reading_wpm" toml:"reading_wpm"`
+	DirectoryListing      bool     `yaml:"directory_listing" toml:"directory_listing"`
+	CrossRefs             bool     `yaml:"cross_refs" toml:"cross_refs"`
+	Mounts                []Mount  `yaml:"mounts" toml:"mounts"`
+	LogFormat             string   `yaml:"log_format" toml:"log_format"`
+	LogLevel              string   `yaml:"log_level" toml:"log_level"`
+	TemplateFile          string   `yaml:"template_file" toml:"template_file"`
+	DevMode               bool     `yaml:"dev_mode" toml:"dev_mode"`
+	EnableMetrics         bool     `yaml:"enable_metrics" toml:"enable_metrics"`
+	PDFConverter          string   `yaml:"pdf_converter" toml:"pdf_converter"`
+	PDFConcurrency        int      `yaml:"pdf_concurrency" toml:"pdf_concurrency"`
+	ShutdownTimeout       int      `yaml:"shutdown_timeout" toml:"shutdown_timeout"`
+	ReadTimeout           int      `yaml:"read_timeout" toml:"read_timeout"`
+	WriteTimeout          int      `yaml:"write_timeout" toml:"write_timeout"`
+	IdleTimeout           int      `yaml:"idle_timeout" toml:"idle_timeout"`
+	MaxBodySize           int      `yaml:"max_body_size" toml:"max_body_size"`
+	TreeCacheTTL          int      `yaml:"tree_cache_ttl" toml:"tree_cache_ttl"`
+
+	// CSPDirectives are sources added to the built-in policy, by directive
+	CSPDirectives map[string]string `yaml:"csp_directives" toml:"csp_directives"`
+}
+
+// defaultCacheControl makes browsers revalidate pages on every use
+const defaultCacheControl = "no-cache"
+
+// defaultConfig returns the configuration used when nothing is overridden
+func defaultConfig() Config {
+	return Config{
+		ContentDir:            "./content",
+		DefaultDocument:       "index.md",
+		Port:                  "8080",
+		EnableSecurityHeaders: true,
+		CSPFrameAncestors:     defaultFrameAncestors,
+		CacheControl:          defaultCacheControl,
+		SiteTitle:             defaultTitle,
+		FeedLimit:             20,
+		ReadingWPM:            defaultReadingWPM,
+		FeedFormat:            feedFormatRSS,
+		SearchIndex:           searchIndexFull,
+		HighlightTheme:        defaultHighlightTheme,
+		LogFormat:             logFormatText,
+		LogLevel:              "info",
+		PDFConcurrency:        defaultPDFConcurrency,
+		AutocertCacheDir:      defaultAutocertCacheDir,
+		ShutdownTimeout:       defaultShutdownTimeout,
+		ReadTimeout:           defaultReadTimeout,
+		WriteTimeout:          defaultWriteTimeout,
+		IdleTimeout:           defaultIdleTimeout,
+		MaxBodySize:           defaultMaxBodySize,
+		RateLimitBurst:        defaultRateLimitBurst,
+	}
+}
+
+// loadConfig builds the configuration from, in increasing order of precedence:
+// built-in defaults, the config file given with -config, environment variables
+// and command-line flags.
+func loadConfig(args []string) (Config, error) {
+	var fromFlags Config
+	flags := flag.NewFlagSet("go-markdown-server", flag.ContinueOnError)
+	configFile := flags.String("config", "", "path to a YAML or TOML config file (env CONFIG_FILE)")
+	flags.StringVar(&fromFlags.ContentDir, "content", "", "directory containing markdown files (env CONTENT_DIR)")
+	flags.StringVar(&fromFlags.Port, "port", "", "port to listen on (env PORT)")
+	flags.StringVar(&fromFlags.BindAddr, "addr", "", "address to bind to, empty for all interfaces (env ADDR)")
+	flags.BoolVar(&fromFlags.EnableSecurityHeaders, "security-headers", true, "send HTTP security headers (env HTTP_SECURITY_HEADERS)")
+	if err := flags.Parse(args); err != nil {
+		return Config{}, err
+	}
+
+	// The config file has to be known before the other environment variables
+	// are applied, since they override it
+	if *configFile == "" {
+		*configFile = os.Getenv("CONFIG_FILE")
+	}
+	config := defaultConfig()
+	if *configFile != "" {
+		if err := config.loadFile(*configFile); err != nil {
+			return Config{}, err
+		}
+	}
+	if err := config.applyEnv(); err != nil {
+		return Config{}, err
+	}
+
+	// Only flags that were actually supplied override earlier sources
+	flags.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "content":
+			config.ContentDir = fromFlags.ContentDir
+		case "port":
+			config.Port = fromFlags.Port
+		case "addr":
+			config.BindAddr = fromFlags.BindAddr
+		case "security-headers":
+			config.EnableSecurityHeaders = fromFlags.EnableSecurityHeaders
+		}
+	})
+
+	config.SiteBaseURL = strings.TrimSuffix(config.SiteBaseURL, "/")
+	// An explicit CACHE_CONTROL is kept as written
+	if config.CacheMaxAge > 0 && config.CacheControl == defaultCacheControl {
+		config.CacheControl = fmt.Sprintf("public, max-age=%d", config.CacheMaxAge)
+	}
+	if len(config.AutocertDomains) > 0 && config.HTTPSPort == "" {
+		config.HTTPSPort = "443"
+	}
+	return config, config.validate()
+}
+
+// loadFile reads a YAML (.yaml, .yml) or TOML (.toml) config file over the
+// current values. A missing file leaves the configuration unchanged.
+func (c *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		slog.Info("config file not found, using defaults", "path", path)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+			return fmt.Errorf("malformed config file %s: %w", path, err)
+		}
+	case ".toml":
+		meta, err := toml.Decode(string(data), c)
+		if err != nil {
+			return fmt.Errorf("malformed config file %s: %w", path, err)
+		}
+		if undecoded := meta.Undecoded(); len(undecoded) > 0 {
+			return fmt.Errorf("malformed config file %s: unknown key %q", path, undecoded[0].String())
+		}
+	default:
+		return fmt.Errorf("unsupported config file format %q: expected .yaml, .yml or .toml", filepath.Ext(path))
+	}
+	return nil
+}
+
+// applyEnv overrides the configuration with any environment variables that are set
+func (c *Config) applyEnv() error {
+	envString(&c.ContentDir, "CONTENT_DIR")
+	envString(&c.DefaultDocument, "DEFAULT_DOCUMENT")
+	envString(&c.AssetsDir, "ASSETS_DIR")
+	envString(&c.Port, "PORT")
+	envString(&c.BindAddr, "ADDR")
+	// Security headers are only disabled by the explicit value "disable"
+	if value := os.Getenv("HTTP_SECURITY_HEADERS"); value != "" {
+		c.EnableSecurityHeaders = value != "disable"
+	}
+	envString(&c.CSP, "CSP_POLICY")
+	envString(&c.CSPFrameAncestors, "CSP_FRAME_ANCESTORS")
+	if value := os.Getenv("CSP_DIRECTIVES"); value != "" {
+		c.CSPDirectives = parseCSPDirectives(value)
+	}
+	envBool(&c.DisableCSP, "DISABLE_CSP")
+	envString(&c.FramePolicy, "FRAME_POLICY")
+	envString(&c.CacheControl, "CACHE_CONTROL")
+	if err := envInt(&c.CacheMaxAge, "CACHE_MAX_AGE"); err != nil {
+		return err
+	}
+	if err := envInt(&c.AssetMaxAge, "ASSET_MAX_AGE"); err != nil {
+		return err
+	}
+	envString(&c.SingleH1, "SINGLE_H1")
+	envString(&c.CanonicalURLs, "CANONICAL_URLS")
+	envBool(&c.LiveReload, "LIVE_RELOAD")
+	envString(&c.TLSCertFile, "TLS_CERT")
+	envString(&c.TLSKeyFile, "TLS_KEY")
+	envString(&c.TLSCertFile, "TLS_CERT_FILE")
+	envString(&c.TLSKeyFile, "TLS_KEY_FILE")
+	envBool(&c.HTTP3, "HTTP3")
+	envString(&c.HTTPSPort, "HTTPS_PORT")
+	envBool(&c.TLSRedirect, "HTTP_REDIRECT")
+	envBool(&c.TLSRedirect, "TLS_REDIRECT")
+	envList(&c.AutocertDomains, "AUTOCERT_DOMAINS")
+	envString(&c.AutocertCacheDir, "AUTOCERT_CACHE_DIR")
+	envString(&c.AutocertEmail, "AUTOCERT_EMAIL")
+	envString(&c.FeedFormat, "FEED_FORMAT")
+	envString(&c.SearchIndex, "SEARCH_INDEX")
+	if err := envInt(&c.SearchMaxTerms, "SEARCH_MAX_TERMS"); err != nil {
+		return err
+	}
+	envBool(&c.PreviewMode, "PREVIEW_MODE")
+	// SHOW_DRAFTS is only an alias, so it can't override PREVIEW_MODE
+	if os.Getenv("PREVIEW_MODE") == "" {
+		envBool(&c.PreviewMode, "SHOW_DRAFTS")
+	}
+	envString(&c.HighlightTheme, "HIGHLIGHT_THEME")
+	envBool(&c.DisableMath, "DISABLE_MATH")
+	envBool(&c.GenerateTOC, "GENERATE_TOC")
+	envBool(&c.HeadingAnchors, "HEADING_ANCHORS")
+	envBool(&c.EnableEmoji, "ENABLE_EMOJI")
+	envBool(&c.WikiLinks, "WIKI_LINKS")
+	envBool(&c.StructuredData, "STRUCTURED_DATA")
+	envBool(&c.Sidebar, "SIDEBAR")
+	envBool(&c.PageNavAcrossDirs, "PAGE_NAV_ACROSS_DIRS")
+	envString(&c.SiteTitle, "SITE_TITLE")
+	envString(&c.SiteDescription, "SITE_DESCRIPTION")
+	envString(&c.SiteBaseURL, "SITE_BASE_URL")
+	envString(&c.EditBaseURL, "EDIT_BASE_URL")
+	if err := envInt(&c.FeedLimit, "FEED_LIMIT"); err != nil {
+		return err
+	}
+	if err := envInt(&c.ReadingWPM, "READING_WPM"); err != nil {
+		return err
+	}
+	envBool(&c.DirectoryListing, "DIRECTORY_LISTING")
+	// DISABLE_DIR_LISTING is the inverted setting from before listings were
+	// opt-in, honoured until deployments have moved to DIRECTORY_LISTING
+	if value := os.Getenv("DISABLE_DIR_LISTING"); value != "" {
+		slog.Warn("DISABLE_DIR_LISTING is deprecated, use DIRECTORY_LISTING instead")
+		if os.Getenv("DIRECTORY_LISTING") == "" {
+			c.DirectoryListing = value != "true"
+		}
+	}
+	envBool(&c.CrossRefs, "CROSS_REFS")
+	envString(&c.LogFormat, "LOG_FORMAT")
+	envString(&c.LogLevel, "LOG_LEVEL")
+	envString(&c.TemplateFile, "TEMPLATE_FILE")
+	envBool(&c.DevMode, "DEV_MODE")
+	envBool(&c.EnableMetrics, "ENABLE_METRICS")
+	envString(&c.PDFConverter, "PDF_CONVERTER")
+	if err := envInt(&c.PDFConcurrency, "PDF_CONCURRENCY"); err != nil {
+		return err
+	}
+	if err := envInt(&c.ShutdownTimeout, "SHUTDOWN_TIMEOUT"); err != nil {
+		return err
+	}
+	if err := envInt(&c.ReadTimeout, "READ_TIMEOUT"); err != nil {
+		return err
+	}
+	if err := envInt(&c.WriteTimeout, "WRITE_TIMEOUT"); err != nil {
+		return err
+	}
+	if err := envInt(&c.IdleTimeout, "IDLE_TIMEOUT"); err != nil {
+		return err
+	}
+	if err := envInt(&c.MaxBodySize, "MAX_BODY_SIZE"); err != nil {
+		return err
+	}
+	if err := envInt(&c.TreeCacheTTL, "TREE_CACHE_TTL"); err != nil {
+		return err
+	}
+	envString(&c.BasicAuthUser, "BASIC_AUTH_USER")
+	envString(&c.BasicAuthPass, "BASIC_AUTH_PASS")
+	envList(&c.BasicAuthExempt, "BASIC_AUTH_EXEMPT")
+	envString(&c.BasicAuthFile, "BASIC_AUTH_FILE")
+	envList(&c.BasicAuthPaths, "BASIC_AUTH_PATHS")
+	if err := envFloat(&c.RateLimit, "RATE_LIMIT"); err != nil {
+		return err
+	}
+	if err := envInt(&c.RateLimitBurst, "RATE_LIMIT_BURST"); err != nil {
+		return err
+	}
+	envBool(&c.TrustProxy, "TRUST_PROXY")
+	envList(&c.IPAllow, "IP_ALLOW")
+	envList(&c.IPDeny, "IP_DENY")
+	envList(&c.RobotsDisallow, "ROBOTS_DISALLOW")
+	if value := os.Getenv("MOUNTS"); value != "" {
+		mounts, err := parseMounts(value)
+		if err != nil {
+			return err
+		}
+		c.Mounts = mounts
+	}
+	return nil
+}
+
+// logSummary logs the effective configuration at startup
+func (c Config) logSummary() {
+	var attrs []any
+	if len(c.Mounts) == 0 {
+		attrs = append(attrs, "content_dir", c.ContentDir)
+	}
+	for _, m := range c.Mounts {
+		attrs = append(attrs, "mount", m.Prefix+" -> "+m.Dir)
+	}
+	attrs = append(attrs,
+		"addr", c.listenAddr(),
+		"security_headers", c.EnableSecurityHeaders,
+		"cache_control", c.CacheControl,
+		"tls", c.TLSCertFile != "" || len(c.AutocertDomains) > 0,
+	)
+	if c.TLSCertFile != "" {
+		attrs = append(attrs, "https_addr", c.httpsAddr(), "https_redirect", c.TLSRedirect)
+	}
+	if len(c.AutocertDomains) > 0 {
+		attrs = append(attrs, "autocert_domains", strings.Join(c.AutocertDomains, ","), "https_addr", c.httpsAddr())
+	}
+	attrs = append(attrs,
+		"http3", c.HTTP3,
+		"live_reload", c.LiveReload,
+		"preview_mode", c.PreviewMode,
+		"basic_auth", c.BasicAuthUser != "" || c.BasicAuthFile != "",
+	)
+	slog.Info("effective configuration", attrs...)
+}
+
+// logLevel parses LOG_LEVEL
+func (c Config) logLevel() (slog.Level, error) {
+	var level slog.Level
+	err := level.UnmarshalText([]byte(c.LogLevel))
+	return level, err
+}
+
+// listenAddr returns the host:port address the server listens on
+func (c Config) listenAddr() string {
+	return net.JoinHostPort(c.BindAddr, c.Port)
+}
+
+// httpsAddr returns the host:port address for HTTPS. Without HTTPS_PORT it is
+// the same as listenAddr.
+func (c Config) httpsAddr() string {
+	if c.HTTPSPort == "" {
+		return c.listenAddr()
+	}
+	return net.JoinHostPort(c.BindAddr, c.HTTPSPort)
+}
+
+// validate checks the configuration for invalid or inconsistent values
+func (c Config) validate() error {
+	if filepath.Ext(c.DefaultDocument) != ".md" || c.DefaultDocument != filepath.Base(c.DefaultDocument) {
+		return fmt.Errorf("invalid DEFAULT_DOCUMENT %q: must be a .md file name", c.DefaultDocument)
+	}
+	if port, err := strconv.Atoi(c.Port); err != nil || port < 1 || port > 65535 {
+		return fmt.Errorf("invalid PORT %q: must be a number between 1 and 65535", c.Port)
+	}
+	if _, err := net.ResolveTCPAddr("tcp", c.listenAddr()); err != nil {
+		return fmt.Errorf("invalid ADDR %q: %w", c.BindAddr, err)
+	}
+	if c.SingleH1 != "" && c.SingleH1 != singleH1Warn && c.SingleH1 != singleH1Demote {
+		return fmt.Errorf("invalid SINGLE_H1 value %q: expected %q or %q", c.SingleH1, singleH1Warn, singleH1Demote)
+	}
+	if c.CanonicalURLs != "" && c.CanonicalURLs != canonicalStrip && c.CanonicalURLs != canonicalSlash {
+		return fmt.Errorf("invalid CANONICAL_URLS %q: expected %q or %q", c.CanonicalURLs, canonicalStrip, canonicalSlash)
+	}
+	if (c.TLSCertFile == "") != (c.TLSKeyFile == "") {
+		return fmt.Errorf("TLS_CERT_FILE and TLS_KEY_FILE must be set together")
+	}
+	if c.TLSCertFile != "" {
+		// Fail at startup rather than when the first client connects
+		if _, err := tls.LoadX509KeyPair(c.TLSCertFile, c.TLSKeyFile); err != nil {
+			return fmt.Errorf("invalid TLS_CERT_FILE or TLS_KEY_FILE: %w", err)
+		}
+	}
+	if c.HTTPSPort != "" {
+		if port, err := strconv.Atoi(c.HTTPSPort); err != nil || port < 1 || port > 65535 {
+			return fmt.Errorf("invalid HTTPS_PORT %q: must be a number between 1 and 65535", c.HTTPSPort)
+		}
+	}
+	if c.TLSRedirect && c.TLSCertFile == "" {
+		return fmt.Errorf("TLS_REDIRECT requires TLS_CERT_FILE and TLS_KEY_FILE")
+	}
+	if c.TLSRedirect && (c.HTTPSPort == "" || c.HTTPSPort == c.Port) {
+		return fmt.Errorf("TLS_REDIRECT requires HTTPS_PORT to be set to a different port than PORT")
+	}
+	if (c.BasicAuthUser == "") != (c.BasicAuthPass == "") {
+		return fmt.Errorf("BASIC_AUTH_USER and BASIC_AUTH_PASS must be set together")
+	}
+	if c.BasicAuthFile != "" && c.BasicAuthUser != "" {
+		return fmt.Errorf("BASIC_AUTH_FILE cannot be combined with BASIC_AUTH_USER and BASIC_AUTH_PASS")
+	}
+	for _, prefix := range c.BasicAuthPaths {
+		if !strings.HasPrefix(prefix, "/") {
+			return fmt.Errorf("invalid BASIC_AUTH_PATHS entry %q: must start with /", prefix)
+		}
+	}
+	if len(c.AutocertDomains) > 0 {
+		if c.TLSCertFile != "" {
+			return fmt.Errorf("AUTOCERT_DOMAINS cannot be combined with TLS_CERT_FILE and TLS_KEY_FILE")
+		}
+		if c.HTTP3 {
+			return fmt.Errorf("HTTP3 is not supported with AUTOCERT_DOMAINS")
+		}
+		if c.AutocertCacheDir == "" {
+			return fmt.Errorf("AUTOCERT_DOMAINS requires AUTOCERT_CACHE_DIR")
+		}
+	}
+	if c.HTTP3 && c.TLSCertFile == "" {
+		return fmt.Errorf("HTTP3 requires TLS_CERT_FILE and TLS_KEY_FILE")
+	}
+	if c.LogFormat != logFormatText && c.LogFormat != logFormatJSON && c.LogFormat != logFormatCombined {
+		return fmt.Errorf("invalid LOG_FORMAT %q: expected %q, %q or %q", c.LogFormat, logFormatText, logFormatJSON, logFormatCombined)
+	}
+	if _, err := c.logLevel(); err != nil {
+		return fmt.Errorf("invalid LOG_LEVEL %q: expected debug, info, warn or error", c.LogLevel)
+	}
+	if len(c.CSPDirectives) > 0 && c.CSP != "" {
+		return fmt.Errorf("CSP_DIRECTIVES extends the built-in policy and cannot be combined with CSP_POLICY")
+	}
+	if err := validateCSPDirectives(c.CSPDirectives); err != nil {
+		return err
+	}
+	if err := validateFramePolicy(c.FramePolicy); err != nil {
+		return err
+	}
+	if c.EditBaseURL != "" && !validEditBaseURL(c.EditBaseURL) {
+		return fmt.Errorf("invalid EDIT_BASE_URL %q: must be an http or https URL", c.EditBaseURL)
+	}
+	if err := validateMounts(c.Mounts); err != nil {
+		return err
+	}
+	if _, ok := styles.Registry[c.HighlightTheme]; !ok {
+		return fmt.Errorf("unknown HIGHLIGHT_THEME %q: see https://xyproto.github.io/splash/docs/ for available themes", c.HighlightTheme)
+	}
+	if c.FeedFormat != feedFormatRSS && c.FeedFormat != feedFormatAtom {
+		return fmt.Errorf("invalid FEED_FORMAT %q: expected %q or %q", c.FeedFormat, feedFormatRSS, feedFormatAtom)
+	}
+	if c.SearchIndex != searchIndexFull && c.SearchIndex != searchIndexTitles {
+		return fmt.Errorf("invalid SEARCH_INDEX %q: expected %q or %q", c.SearchIndex, searchIndexFull, searchIndexTitles)
+	}
+	if c.SearchMaxTerms < 0 {
+		return fmt.Errorf("invalid SEARCH_MAX_TERMS %d: must not be negative", c.SearchMaxTerms)
+	}
+	if c.CacheMaxAge < 0 || c.AssetMaxAge < 0 {
+		return fmt.Errorf("CACHE_MAX_AGE and ASSET_MAX_AGE must not be negative")
+	}
+	if c.AssetsDir != "" {
+		if info, err := os.Stat(c.AssetsDir); err != nil || !info.IsDir() {
+			return fmt.Errorf("ASSETS_DIR %q is not a directory", c.AssetsDir)
+		}
+	}
+	for _, path := range c.RobotsDisallow {
+		if !strings.HasPrefix(path, "/") {
+			return fmt.Errorf("invalid ROBOTS_DISALLOW path %q: must start with /", path)
+		}
+	}
+	if c.FeedLimit < 1 {
+		return fmt.Errorf("invalid FEED_LIMIT %d: must be at least 1", c.FeedLimit)
+	}
+	if c.ReadingWPM < 1 {
+		return fmt.Errorf("invalid READING_WPM %d: must be at least 1", c.ReadingWPM)
+	}
+	if c.PDFConcurrency < 1 {
+		return fmt.Errorf("invalid PDF_CONCURRENCY %d: must be at least 1", c.PDFConcurrency)
+	}
+	if c.ShutdownTimeout < 0 {
+		return fmt.Errorf("invalid SHUTDOWN_TIMEOUT %d: must not be negative", c.ShutdownTimeout)
+	}
+	if c.ReadTimeout < 0 {
+		return fmt.Errorf("invalid READ_TIMEOUT %d: must not be negative", c.ReadTimeout)
+	}
+	if c.WriteTimeout < 0 {
+		return fmt.Errorf("invalid WRITE_TIMEOUT %d: must not be negative", c.WriteTimeout)
+	}
+	if c.IdleTimeout < 0 {
+		return fmt.Errorf("invalid IDLE_TIMEOUT %d: must not be negative", c.IdleTimeout)
+	}
+	if _, err := parseCIDRs("IP_ALLOW", c.IPAllow); err != nil {
+		return err
+	}
+	if _, err := parseCIDRs("IP_DENY", c.IPDeny); err != nil {
+		return err
+	}
+	if c.RateLimit < 0 {
+		return fmt.Errorf("invalid RATE_LIMIT %g: must not be negative", c.RateLimit)
+	}
+	if c.RateLimitBurst < 1 {
+		return fmt.Errorf("invalid RATE_LIMIT_BURST %d: must be at least 1", c.RateLimitBurst)
+	}
+	if c.MaxBodySize < 1 {
+		return fmt.Errorf("invalid MAX_BODY_SIZE %d: must be at least 1", c.MaxBodySize)
+	}
+	if c.TreeCacheTTL < 0 {
+		return fmt.Errorf("invalid TREE_CACHE_TTL %d: must not be negative", c.TreeCacheTTL)
+	}
+	return nil
+}
+
+// envList sets *dst to the comma-separated values of the environment variable
+// if it is set and not empty
+func envList(dst *[]string, key string) {
+	value := os.Getenv(key)
+	if value == "" {
+		return
+	}
+	var list []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	*dst = list
+}
+
+// envString sets *dst to the environment variable's value if it is set and not empty
+func envString(dst *string, key string) {
+	if value := os.Getenv(key); value != "" {
+		*dst = value
+	}
+}
+
+// envBool sets *dst from the environment variable if it is set: "true" enables
+// the option and any other value disables it
+func envBool(dst *bool, key string) {
+	if value := os.Getenv(key); value != "" {
+		*dst = value == "true"
+	}
+}
+
+// envInt sets *dst to the environment variable's integer value if it is set and not empty
+func envInt(dst *int, key string) error {
+	value := os.Getenv(key)
+	if value == "" {
+		return nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return fmt.Errorf("invalid %s %q: must be an integer", key, value)
+	}
+	*dst = n
+	return nil
+}
+
+// envFloat sets *dst to the environment variable's numeric value if it is set and not empty
+func envFloat(dst *float64, key string) error {
+	value := os.Getenv(key)
+	if value == "" {
+		return nil
+	}
+	n, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return fmt.Errorf("invalid %s %q: must be a number", key, value)
+	}
+	*dst = n
+	return nil
+}
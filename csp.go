@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// cspDirectiveName matches Content Security Policy directive names such as script-src
+var cspDirectiveName = regexp.MustCompile(`^[a-z]+(-[a-z]+)*$`)
+
+// cspDirective is one directive of a policy and its sources
+type cspDirective struct {
+	name    string
+	sources string
+}
+
+// parseCSPDirectives parses CSP_DIRECTIVES, directives separated by
+// semicolons in the policy's own syntax, e.g.
+// "script-src https://plausible.io; font-src https://fonts.gstatic.com"
+func parseCSPDirectives(value string) map[string]string {
+	directives := make(map[string]string)
+	for _, directive := range strings.Split(value, ";") {
+		fields := strings.Fields(directive)
+		if len(fields) == 0 {
+			continue
+		}
+		name := strings.ToLower(fields[0])
+		directives[name] = strings.TrimSpace(directives[name] + " " + strings.Join(fields[1:], " "))
+	}
+	return directives
+}
+
+// validateCSPDirectives checks the names and sources of CSP_DIRECTIVES
+func validateCSPDirectives(directives map[string]string) error {
+	for name, sources := range directives {
+		if !cspDirectiveName.MatchString(name) {
+			return fmt.Errorf("invalid CSP_DIRECTIVES directive %q", name)
+		}
+		if strings.ContainsAny(sources, ";,") {
+			return fmt.Errorf("invalid CSP_DIRECTIVES sources %q for %s: must not contain ; or ,", sources, name)
+		}
+	}
+	return nil
+}
+
+// withCSPAdditions appends the sources in additions to the directives of the
+// same name, and adds the other directives at the end, in name order
+func withCSPAdditions(directives []cspDirective, additions map[string]string) string {
+	names := make([]string, 0, len(additions))
+	for name := range additions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		found := false
+		for i := range directives {
+			if directives[i].name == name {
+				directives[i].sources = strings.TrimSpace(directives[i].sources + " " + additions[name])
+				found = true
+			}
+		}
+		if !found {
+			directives = append(directives, cspDirective{name, additions[name]})
+		}
+	}
+
+	parts := make([]string, len(directives))
+	for i, d := range directives {
+		parts[i] = strings.TrimSpace(d.name + " " + d.sources)
+	}
+	return strings.Join(parts, "; ")
+}
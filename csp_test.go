@@ -0,0 +1,105 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestContentSecurityPolicy(t *testing.T) {
+	tests := []struct {
+		name      string
+		configure func(*Config)
+		want      []string
+	}{
+		{"default", nil, []string{"default-src 'self'", "script-src 'self';", "frame-ancestors *"}},
+		{"directive appended", func(c *Config) {
+			c.CSPDirectives = map[string]string{"script-src": "https://plausible.io"}
+		}, []string{"script-src 'self' https://plausible.io;"}},
+		{"directive added", func(c *Config) {
+			c.CSPDirectives = map[string]string{"worker-src": "'self' blob:"}
+		}, []string{"; worker-src 'self' blob:"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServer(t, map[string]string{"index.md": "# Home\n"}, tt.configure)
+			policy := get(s, "/").Header().Get("Content-Security-Policy")
+			for _, want := range tt.want {
+				if !strings.Contains(policy, want) {
+					t.Errorf("Content-Security-Policy = %q, want it to contain %q", policy, want)
+				}
+			}
+		})
+	}
+}
+
+func TestCustomCSPUnchanged(t *testing.T) {
+	custom := "default-src 'none'; img-src https://cdn.example.com; style-src 'self'"
+	s := newTestServer(t, map[string]string{"index.md": "# Home\n"}, func(c *Config) { c.CSP = custom })
+	if policy := get(s, "/").Header().Get("Content-Security-Policy"); policy != custom {
+		t.Errorf("Content-Security-Policy = %q, want %q unchanged", policy, custom)
+	}
+}
+
+func TestDisableCSP(t *testing.T) {
+	s := newTestServer(t, map[string]string{"index.md": "# Home\n"}, func(c *Config) { c.DisableCSP = true })
+	w := get(s, "/")
+	if policy := w.Header().Get("Content-Security-Policy"); policy != "" {
+		t.Errorf("Content-Security-Policy = %q with DISABLE_CSP", policy)
+	}
+	if w.Header().Get("X-Content-Type-Options") != "nosniff" {
+		t.Error("other security headers dropped with DISABLE_CSP")
+	}
+}
+
+func TestParseCSPDirectives(t *testing.T) {
+	tests := []struct {
+		value string
+		want  map[string]string
+	}{
+		{"", map[string]string{}},
+		{"script-src https://plausible.io", map[string]string{"script-src": "https://plausible.io"}},
+		{
+			"script-src https://a.example;  Font-Src https://fonts.gstatic.com ;",
+			map[string]string{"script-src": "https://a.example", "font-src": "https://fonts.gstatic.com"},
+		},
+		{"script-src https://a.example; script-src https://b.example", map[string]string{"script-src": "https://a.example https://b.example"}},
+		{"upgrade-insecure-requests", map[string]string{"upgrade-insecure-requests": ""}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got := parseCSPDirectives(tt.value)
+			if len(got) != len(tt.want) {
+				t.Fatalf("parseCSPDirectives = %v, want %v", got, tt.want)
+			}
+			for name, sources := range tt.want {
+				if got[name] != sources {
+					t.Errorf("%s = %q, want %q", name, got[name], sources)
+				}
+			}
+		})
+	}
+}
+
+func TestCSPConfig(t *testing.T) {
+	tests := []struct {
+		name       string
+		csp        string
+		directives map[string]string
+		ok         bool
+	}{
+		{"directives", "", map[string]string{"script-src": "https://plausible.io"}, true},
+		{"policy", "default-src 'self'", nil, true},
+		{"policy and directives", "default-src 'self'", map[string]string{"script-src": "https://plausible.io"}, false},
+		{"bad directive name", "", map[string]string{"script src!": "https://plausible.io"}, false},
+		{"injected directive", "", map[string]string{"script-src": "https://a.example, default-src *"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := defaultConfig()
+			config.CSP, config.CSPDirectives = tt.csp, tt.directives
+			if err := config.validate(); (err == nil) != tt.ok {
+				t.Errorf("validate = %v, want ok %v", err, tt.ok)
+			}
+		})
+	}
+}
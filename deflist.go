@@ -0,0 +1,123 @@
+package main
+
+import (
+	"bytes"
+
+	"github.com/gomarkdown/markdown/ast"
+	"github.com/gomarkdown/markdown/parser"
+)
+
+// definitionTermsHook is a parser hook for definition lists whose first
+// definition has several terms, one per line. The parser keeps only the last
+// line as the term and drops the others, so the hook takes the term lines
+// itself and returns them as a paragraph, which splitDefinitionTerms then
+// turns into terms. Each paragraph it returns is added to terms.
+func definitionTermsHook(extensions parser.Extensions, terms map[*ast.Paragraph]bool) parser.BlockFunc {
+	return func(data []byte) (ast.Node, []byte, int) {
+		end, lines := 0, 0
+		for end < len(data) {
+			line := data[end:]
+			if nl := bytes.IndexByte(line, '\n'); nl >= 0 {
+				line = line[:nl+1]
+			}
+			if len(bytes.TrimSpace(line)) == 0 {
+				return nil, nil, 0
+			}
+			if len(line) > 1 && line[0] == ':' && (line[1] == ' ' || line[1] == '\t') {
+				break
+			}
+			end += len(line)
+			lines++
+		}
+		if lines < 2 || end == len(data) {
+			return nil, nil, 0
+		}
+
+		// Lines that start some other block, such as a heading, aren't terms
+		doc := parser.NewWithExtensions(extensions).Parse(data[:end])
+		if children := doc.GetChildren(); len(children) != 1 {
+			return nil, nil, 0
+		} else if _, ok := children[0].(*ast.Paragraph); !ok {
+			return nil, nil, 0
+		}
+
+		para := &ast.Paragraph{}
+		para.Content = bytes.TrimRight(data[:end], "\n")
+		terms[para] = true
+		return para, nil, end
+	}
+}
+
+// splitDefinitionTerms gives each line of a definition list term its own
+// <dt>, and moves the terms definitionTermsHook took into the list they
+// belong to
+func splitDefinitionTerms(doc ast.Node, terms map[*ast.Paragraph]bool) {
+	var lists []*ast.List
+	ast.WalkFunc(doc, func(node ast.Node, entering bool) ast.WalkStatus {
+		if list, ok := node.(*ast.List); ok && entering && list.ListFlags&ast.ListTypeDefinition != 0 {
+			lists = append(lists, list)
+		}
+		return ast.GoToNext
+	})
+
+	for _, list := range lists {
+		var items []ast.Node
+		if para, ok := ast.GetPrevNode(list).(*ast.Paragraph); ok && terms[para] {
+			items = append(items, termItems(list, para)...)
+			ast.RemoveFromTree(para)
+		}
+		for _, child := range list.Children {
+			item, ok := child.(*ast.ListItem)
+			if !ok || item.ListFlags&ast.ListTypeTerm == 0 || len(item.Children) != 1 {
+				items = append(items, child)
+				continue
+			}
+			para, ok := item.Children[0].(*ast.Paragraph)
+			if !ok {
+				items = append(items, child)
+				continue
+			}
+			items = append(items, termItems(list, para)...)
+		}
+		list.Children = items
+	}
+}
+
+// termItems splits the paragraph at line breaks into <dt> items for list
+func termItems(list *ast.List, para *ast.Paragraph) []ast.Node {
+	var items []ast.Node
+	var term *ast.Paragraph
+	add := func(node ast.Node) {
+		if term == nil {
+			term = &ast.Paragraph{}
+			item := &ast.ListItem{ListFlags: ast.ListTypeDefinition | ast.ListTypeTerm, Tight: list.Tight}
+			ast.AppendChild(item, term)
+			item.SetParent(list)
+			items = append(items, item)
+		}
+		// Not ast.AppendChild, which drops the children of nodes it moves
+		node.SetParent(term)
+		term.Children = append(term.Children, node)
+	}
+	for _, child := range para.Children {
+		text, ok := child.(*ast.Text)
+		if !ok {
+			add(child)
+			continue
+		}
+		lines := bytes.Split(text.Literal, []byte("\n"))
+		for i, line := range lines {
+			if i > 0 {
+				term = nil
+				line = bytes.TrimLeft(line, " \t")
+			}
+			if i < len(lines)-1 {
+				line = bytes.TrimRight(line, " \t")
+			}
+			if len(line) > 0 {
+				add(&ast.Text{Leaf: ast.Leaf{Literal: line}})
+			}
+		}
+	}
+	return items
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDefinitionLists(t *testing.T) {
+	tests := []struct {
+		name string
+		md   string
+		want string
+	}{
+		{
+			"single term",
+			"Apple\n:   A red fruit.\n",
+			"<dl><dt>Apple</dt><dd>A red fruit.</dd></dl>",
+		},
+		{
+			"glossary",
+			"Apple\nPomme\n:   A red fruit.\n\n    It grows on trees.\n\nBanana\n:   A yellow fruit.\n",
+			"<dl><dt>Apple</dt><dt>Pomme</dt><dd><p>A red fruit.</p><p>It grows on trees.</p></dd>" +
+				"<dt>Banana</dt><dd><p>A yellow fruit.</p></dd></dl>",
+		},
+		{
+			"several terms later in the list",
+			"Apple\n:   A red fruit.\n\nBanana\nPlátano\n:   A yellow fruit.\n",
+			"<dl><dt>Apple</dt><dd>A red fruit.</dd><dt>Banana</dt><dt>Plátano</dt><dd>A yellow fruit.</dd></dl>",
+		},
+		{
+			"formatted terms",
+			"*Apple*\n`pomme`\n:   A red fruit.\n",
+			"<dl><dt><em>Apple</em></dt><dt><code>pomme</code></dt><dd>A red fruit.</dd></dl>",
+		},
+		{
+			"heading is not a term",
+			"# Fruit\nApple\n:   A red fruit.\n",
+			`<h1 id="fruit">Fruit</h1><dl><dt>Apple</dt><dd>A red fruit.</dd></dl>`,
+		},
+		{
+			"paragraph without a definition",
+			"Apple\nPomme\n\nBanana\n",
+			"<p>Apple\nPomme</p><p>Banana</p>",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServer(t, nil, nil)
+			html := s.markdownToHTML([]byte(tt.md), "Glossary", "glossary.md").HTML
+			// Compare the structure, ignoring the line breaks between elements
+			got := strings.NewReplacer(">\n\n<", "><", ">\n<", "><").Replace(strings.TrimSpace(html))
+			if got != tt.want {
+				t.Errorf("markdownToHTML(%q) =\n%s\nwant\n%s", tt.md, got, tt.want)
+			}
+		})
+	}
+}
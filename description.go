@@ -0,0 +1,30 @@
+package main
+
+import (
+	"github.com/gomarkdown/markdown/ast"
+	"github.com/gomarkdown/markdown/parser"
+)
+
+// descriptionLength is the maximum length of a description taken from a
+// page's text, about what search engines show
+const descriptionLength = 160
+
+// pageDescription returns the frontmatter description if there is one,
+// otherwise the start of the page's first top-level paragraph. It returns ""
+// for pages that have no paragraphs, such as ones made only of headings.
+func pageDescription(meta map[string]interface{}, body []byte) string {
+	if description := metaString(meta, "description"); description != "" {
+		return description
+	}
+
+	doc := parser.NewWithExtensions(markdownExtensions).Parse(body)
+	for _, node := range doc.GetChildren() {
+		if para, ok := node.(*ast.Paragraph); ok {
+			text := mdWhitespaces.ReplaceAllString(plainText(para), " ")
+			if text != "" {
+				return excerpt(text, descriptionLength)
+			}
+		}
+	}
+	return ""
+}
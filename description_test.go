@@ -0,0 +1,75 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPageDescription(t *testing.T) {
+	long := strings.Repeat("lorem ipsum ", 20)
+	tests := []struct {
+		name string
+		meta map[string]interface{}
+		body string
+		want string
+	}{
+		{"frontmatter", map[string]interface{}{"description": "From frontmatter"}, "# Title\n\nFirst paragraph.\n", "From frontmatter"},
+		{"first paragraph", nil, "# Title\n\nFirst paragraph\nwrapped here.\n\nSecond paragraph.\n", "First paragraph wrapped here."},
+		{"formatting stripped", nil, "See [the guide](guide.md) for more.\n", "See the guide for more."},
+		{"code block skipped", nil, "```\ncode\n```\n\nAfter the code.\n", "After the code."},
+		{"truncated", nil, long, excerpt(strings.TrimSpace(long), descriptionLength)},
+		{"only headings", nil, "# Title\n\n## Section\n", ""},
+		{"empty", nil, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := pageDescription(tt.meta, []byte(tt.body)); got != tt.want {
+				t.Errorf("pageDescription = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExcerpt(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		max  int
+		want string
+	}{
+		{"short", "Short text.", 20, "Short text."},
+		{"exact", "Exactly ten", 11, "Exactly ten"},
+		{"word boundary", "The quick brown fox jumps", 15, "The quick…"},
+		{"trailing punctuation", "First, second, third", 14, "First…"},
+		{"no spaces", "Supercalifragilistic", 5, "Super…"},
+		{"multibyte cut", "Grüße aus Köln", 3, "Gr…"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := excerpt(tt.text, tt.max); got != tt.want {
+				t.Errorf("excerpt(%q, %d) = %q, want %q", tt.text, tt.max, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDescriptionMetaTag(t *testing.T) {
+	s := newTestServer(t, map[string]string{
+		"post.md":     "# Post\n\nA short summary of the post.\n",
+		"headings.md": "# Only\n\n## Headings\n",
+	}, func(c *Config) { c.SiteTitle = "My Site" })
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/post", `<meta name="description" content="A short summary of the post.">`},
+		{"/headings", `<meta name="description" content="My Site">`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if body := get(s, tt.path).Body.String(); !strings.Contains(body, tt.want) {
+				t.Errorf("page has no %s:\n%s", tt.want, body)
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// isDraft reports whether a page is unpublished: marked `draft: true` in its
+// frontmatter, or with a leading underscore in its file name or the name of a
+// directory it is in, like _release-notes.md or _partials/warning.md. relPath
+// is relative to the content directory.
+func isDraft(meta map[string]interface{}, relPath string) bool {
+	if metaBool(meta, "draft") {
+		return true
+	}
+	for _, segment := range strings.Split(filepath.ToSlash(relPath), "/") {
+		if strings.HasPrefix(segment, "_") {
+			return true
+		}
+	}
+	return false
+}
+
+// isPublic reports whether a page or directory may be served and listed.
+// Every feature that serves or lists pages (page requests, the JSON API,
+// directory listings, the sidebar, previous/next links, search, feeds and the
+// sitemap) goes through this one rule: drafts are public only in preview mode.
+func (s *Server) isPublic(meta map[string]interface{}, relPath string) bool {
+	return s.config.PreviewMode || !isDraft(meta, relPath)
+}
+
+// isPublicFile is isPublic for a page that hasn't been read yet, using the
+// frontmatter read into the content tree. Pages missing from the tree are
+// judged by their path alone.
+func (s *Server) isPublicFile(relPath string) bool {
+	if s.config.PreviewMode {
+		return true
+	}
+	if file, ok := s.treeFile(relPath); ok {
+		return !file.page.draft
+	}
+	return !isDraft(nil, relPath)
+}
+
+// contentRelPath returns filePath relative to the content directory, with
+// forward slashes, or "" if it is outside it
+func (s *Server) contentRelPath(filePath string) string {
+	relPath, err := filepath.Rel(s.config.ContentDir, filePath)
+	if err != nil || strings.HasPrefix(relPath, "..") {
+		return ""
+	}
+	return filepath.ToSlash(relPath)
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestIsDraft(t *testing.T) {
+	tests := []struct {
+		name    string
+		meta    map[string]interface{}
+		relPath string
+		want    bool
+	}{
+		{"published", nil, "guide.md", false},
+		{"draft flag", map[string]interface{}{"draft": true}, "guide.md", true},
+		{"draft false", map[string]interface{}{"draft": false}, "guide.md", false},
+		{"underscore file", nil, "_release-notes.md", true},
+		{"underscore directory", nil, "_partials/warning.md", true},
+		{"underscore inside name", nil, "my_page.md", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isDraft(tt.meta, tt.relPath); got != tt.want {
+				t.Errorf("isDraft = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDraftPages(t *testing.T) {
+	files := map[string]string{
+		"index.md":   "# Home\n",
+		"draft.md":   "---\ntitle: Upcoming\ndraft: true\n---\n\nNot yet.\n",
+		"_hidden.md": "---\ntitle: Hidden\n---\n",
+		"public.md":  "---\ntitle: Public\ndraft: false\n---\n",
+	}
+	tests := []struct {
+		name    string
+		preview bool
+		path    string
+		status  int
+		banner  bool
+	}{
+		{"draft hidden", false, "/draft", http.StatusNotFound, false},
+		{"underscore draft hidden", false, "/_hidden", http.StatusNotFound, false},
+		{"published page", false, "/public", http.StatusOK, false},
+		{"draft in preview", true, "/draft", http.StatusOK, true},
+		{"underscore draft in preview", true, "/_hidden", http.StatusOK, true},
+		{"published page in preview", true, "/public", http.StatusOK, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServer(t, files, func(c *Config) { c.PreviewMode = tt.preview })
+			w := get(s, tt.path)
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d", w.Code, tt.status)
+			}
+			if banner := strings.Contains(w.Body.String(), `class="draft-banner"`); banner != tt.banner {
+				t.Errorf("draft banner shown = %v, want %v", banner, tt.banner)
+			}
+		})
+	}
+}
+
+func TestPreviewModeEnv(t *testing.T) {
+	tests := []struct {
+		name        string
+		previewMode string
+		showDrafts  string
+		want        bool
+	}{
+		{"default", "", "", false},
+		{"preview mode", "true", "", true},
+		{"alias", "", "true", true},
+		{"preview mode wins over alias", "true", "false", true},
+		{"disabled preview mode wins over alias", "false", "true", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("PREVIEW_MODE", tt.previewMode)
+			t.Setenv("SHOW_DRAFTS", tt.showDrafts)
+			config, err := loadConfig(nil)
+			if err != nil {
+				t.Fatalf("loadConfig: %v", err)
+			}
+			if config.PreviewMode != tt.want {
+				t.Errorf("PreviewMode = %v, want %v", config.PreviewMode, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"net/url"
+	"strings"
+)
+
+// editURL returns the link to edit the page at relPath, relative to the
+// content directory, in its source repository: EDIT_BASE_URL followed by the
+// path. It returns "" when no EDIT_BASE_URL is configured.
+func (s *Server) editURL(relPath string) string {
+	if s.config.EditBaseURL == "" {
+		return ""
+	}
+	segments := strings.Split(relPath, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.TrimSuffix(s.config.EditBaseURL, "/") + "/" + strings.Join(segments, "/")
+}
+
+// validEditBaseURL reports whether base is an absolute http or https URL
+func validEditBaseURL(base string) bool {
+	u, err := url.Parse(base)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
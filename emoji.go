@@ -0,0 +1,396 @@
+package main
+
+import (
+	"regexp"
+
+	"github.com/gomarkdown/markdown/ast"
+)
+
+// emojiShortcode matches a GitHub-style shortcode such as :rocket:
+var emojiShortcode = regexp.MustCompile(`:[a-z0-9_+-]+:`)
+
+// replaceEmojiShortcodes replaces known shortcodes in the document's text with
+// their emoji. Code spans and code blocks are separate node types, so
+// shortcodes in code samples are left as written, as are unknown shortcodes.
+func replaceEmojiShortcodes(doc ast.Node) {
+	ast.WalkFunc(doc, func(node ast.Node, entering bool) ast.WalkStatus {
+		if text, ok := node.(*ast.Text); ok && entering {
+			text.Literal = emojiShortcode.ReplaceAllFunc(text.Literal, func(code []byte) []byte {
+				if emoji, ok := emojis[string(code[1:len(code)-1])]; ok {
+					return []byte(emoji)
+				}
+				return code
+			})
+		}
+		return ast.GoToNext
+	})
+}
+
+// emojis maps shortcodes, without the colons, to emoji. It covers the
+// shortcodes most used in documentation and notes, with GitHub's names.
+var emojis = map[string]string{
+	// Faces
+	"smile":                        "😄",
+	"smiley":                       "😃",
+	"grinning":                     "😀",
+	"grin":                         "😁",
+	"laughing":                     "😆",
+	"satisfied":                    "😆",
+	"sweat_smile":                  "😅",
+	"joy":                          "😂",
+	"rofl":                         "🤣",
+	"slightly_smiling_face":        "🙂",
+	"upside_down_face":             "🙃",
+	"wink":                         "😉",
+	"blush":                        "😊",
+	"innocent":                     "😇",
+	"heart_eyes":                   "😍",
+	"star_struck":                  "🤩",
+	"kissing_heart":                "😘",
+	"yum":                          "😋",
+	"stuck_out_tongue":             "😛",
+	"stuck_out_tongue_winking_eye": "😜",
+	"thinking":                     "🤔",
+	"neutral_face":                 "😐",
+	"expressionless":               "😑",
+	"no_mouth":                     "😶",
+	"smirk":                        "😏",
+	"unamused":                     "😒",
+	"roll_eyes":                    "🙄",
+	"grimacing":                    "😬",
+	"relieved":                     "😌",
+	"pensive":                      "😔",
+	"sleepy":                       "😪",
+	"sleeping":                     "😴",
+	"mask":                         "😷",
+	"nerd_face":                    "🤓",
+	"sunglasses":                   "😎",
+	"confused":                     "😕",
+	"worried":                      "😟",
+	"slightly_frowning_face":       "🙁",
+	"open_mouth":                   "😮",
+	"astonished":                   "😲",
+	"flushed":                      "😳",
+	"pleading_face":                "🥺",
+	"fearful":                      "😨",
+	"cold_sweat":                   "😰",
+	"cry":                          "😢",
+	"sob":                          "😭",
+	"scream":                       "😱",
+	"disappointed":                 "😞",
+	"sweat":                        "😓",
+	"weary":                        "😩",
+	"tired_face":                   "😫",
+	"yawning_face":                 "🥱",
+	"triumph":                      "😤",
+	"rage":                         "😡",
+	"angry":                        "😠",
+	"exploding_head":               "🤯",
+	"partying_face":                "🥳",
+	"hugs":                         "🤗",
+	"shushing_face":                "🤫",
+	"zipper_mouth_face":            "🤐",
+	"face_with_monocle":            "🧐",
+	"skull":                        "💀",
+	"ghost":                        "👻",
+	"alien":                        "👽",
+	"robot":                        "🤖",
+	"poop":                         "💩",
+	"hankey":                       "💩",
+	"see_no_evil":                  "🙈",
+	"hear_no_evil":                 "🙉",
+	"speak_no_evil":                "🙊",
+
+	// Hands and people
+	"+1":              "👍",
+	"thumbsup":        "👍",
+	"-1":              "👎",
+	"thumbsdown":      "👎",
+	"ok_hand":         "👌",
+	"wave":            "👋",
+	"clap":            "👏",
+	"raised_hands":    "🙌",
+	"pray":            "🙏",
+	"handshake":       "🤝",
+	"muscle":          "💪",
+	"point_up":        "☝️",
+	"point_down":      "👇",
+	"point_left":      "👈",
+	"point_right":     "👉",
+	"raised_hand":     "✋",
+	"hand":            "✋",
+	"v":               "✌️",
+	"crossed_fingers": "🤞",
+	"metal":           "🤘",
+	"fist":            "✊",
+	"facepunch":       "👊",
+	"punch":           "👊",
+	"writing_hand":    "✍️",
+	"eyes":            "👀",
+	"eye":             "👁️",
+	"brain":           "🧠",
+	"man_shrugging":   "🤷‍♂️",
+	"woman_shrugging": "🤷‍♀️",
+	"shrug":           "🤷",
+	"facepalm":        "🤦",
+	"technologist":    "🧑‍💻",
+	"ninja":           "🥷",
+
+	// Hearts and symbols
+	"heart":                       "❤️",
+	"orange_heart":                "🧡",
+	"yellow_heart":                "💛",
+	"green_heart":                 "💚",
+	"blue_heart":                  "💙",
+	"purple_heart":                "💜",
+	"black_heart":                 "🖤",
+	"broken_heart":                "💔",
+	"sparkling_heart":             "💖",
+	"100":                         "💯",
+	"boom":                        "💥",
+	"collision":                   "💥",
+	"sparkles":                    "✨",
+	"star":                        "⭐",
+	"star2":                       "🌟",
+	"dizzy":                       "💫",
+	"zap":                         "⚡",
+	"fire":                        "🔥",
+	"tada":                        "🎉",
+	"confetti_ball":               "🎊",
+	"balloon":                     "🎈",
+	"gift":                        "🎁",
+	"trophy":                      "🏆",
+	"medal_sports":                "🏅",
+	"1st_place_medal":             "🥇",
+	"dart":                        "🎯",
+	"zzz":                         "💤",
+	"speech_balloon":              "💬",
+	"thought_balloon":             "💭",
+	"white_check_mark":            "✅",
+	"heavy_check_mark":            "✔️",
+	"ballot_box_with_check":       "☑️",
+	"x":                           "❌",
+	"negative_squared_cross_mark": "❎",
+	"heavy_multiplication_x":      "✖️",
+	"heavy_plus_sign":             "➕",
+	"heavy_minus_sign":            "➖",
+	"warning":                     "⚠️",
+	"no_entry":                    "⛔",
+	"no_entry_sign":               "🚫",
+	"stop_sign":                   "🛑",
+	"construction":                "🚧",
+	"rotating_light":              "🚨",
+	"information_source":          "ℹ️",
+	"question":                    "❓",
+	"grey_question":               "❔",
+	"exclamation":                 "❗",
+	"heavy_exclamation_mark":      "❗",
+	"grey_exclamation":            "❕",
+	"bangbang":                    "‼️",
+	"interrobang":                 "⁉️",
+	"arrow_up":                    "⬆️",
+	"arrow_down":                  "⬇️",
+	"arrow_left":                  "⬅️",
+	"arrow_right":                 "➡️",
+	"arrows_counterclockwise":     "🔄",
+	"repeat":                      "🔁",
+	"recycle":                     "♻️",
+	"red_circle":                  "🔴",
+	"orange_circle":               "🟠",
+	"yellow_circle":               "🟡",
+	"green_circle":                "🟢",
+	"large_blue_circle":           "🔵",
+	"purple_circle":               "🟣",
+	"black_circle":                "⚫",
+	"white_circle":                "⚪",
+	"new":                         "🆕",
+	"free":                        "🆓",
+	"up":                          "🆙",
+	"cool":                        "🆒",
+	"ok":                          "🆗",
+	"sos":                         "🆘",
+	"copyright":                   "©️",
+	"registered":                  "®️",
+	"tm":                          "™️",
+
+	// Objects and tools
+	"rocket":                     "🚀",
+	"bulb":                       "💡",
+	"memo":                       "📝",
+	"pencil":                     "📝",
+	"pencil2":                    "✏️",
+	"book":                       "📖",
+	"open_book":                  "📖",
+	"books":                      "📚",
+	"bookmark":                   "🔖",
+	"notebook":                   "📓",
+	"page_facing_up":             "📄",
+	"page_with_curl":             "📃",
+	"clipboard":                  "📋",
+	"pushpin":                    "📌",
+	"round_pushpin":              "📍",
+	"paperclip":                  "📎",
+	"link":                       "🔗",
+	"file_folder":                "📁",
+	"open_file_folder":           "📂",
+	"card_index_dividers":        "🗂️",
+	"calendar":                   "📆",
+	"date":                       "📅",
+	"chart_with_upwards_trend":   "📈",
+	"chart_with_downwards_trend": "📉",
+	"bar_chart":                  "📊",
+	"mag":                        "🔍",
+	"mag_right":                  "🔎",
+	"lock":                       "🔒",
+	"unlock":                     "🔓",
+	"key":                        "🔑",
+	"closed_lock_with_key":       "🔐",
+	"shield":                     "🛡️",
+	"wrench":                     "🔧",
+	"hammer":                     "🔨",
+	"hammer_and_wrench":          "🛠️",
+	"gear":                       "⚙️",
+	"nut_and_bolt":               "🔩",
+	"toolbox":                    "🧰",
+	"package":                    "📦",
+	"bug":                        "🐛",
+	"beetle":                     "🐞",
+	"lady_beetle":                "🐞",
+	"test_tube":                  "🧪",
+	"microscope":                 "🔬",
+	"telescope":                  "🔭",
+	"computer":                   "💻",
+	"desktop_computer":           "🖥️",
+	"keyboard":                   "⌨️",
+	"floppy_disk":                "💾",
+	"cd":                         "💿",
+	"iphone":                     "📱",
+	"phone":                      "☎️",
+	"email":                      "📧",
+	"envelope":                   "✉️",
+	"inbox_tray":                 "📥",
+	"outbox_tray":                "📤",
+	"mailbox":                    "📫",
+	"bell":                       "🔔",
+	"no_bell":                    "🔕",
+	"loudspeaker":                "📢",
+	"mega":                       "📣",
+	"hourglass":                  "⌛",
+	"hourglass_flowing_sand":     "⏳",
+	"alarm_clock":                "⏰",
+	"stopwatch":                  "⏱️",
+	"watch":                      "⌚",
+	"moneybag":                   "💰",
+	"dollar":                     "💵",
+	"credit_card":                "💳",
+	"gem":                        "💎",
+	"scissors":                   "✂️",
+	"wastebasket":                "🗑️",
+	"art":                        "🎨",
+	"camera":                     "📷",
+	"movie_camera":               "🎥",
+	"video_game":                 "🎮",
+	"musical_note":               "🎵",
+	"notes":                      "🎶",
+	"headphones":                 "🎧",
+	"battery":                    "🔋",
+	"electric_plug":              "🔌",
+	"satellite":                  "📡",
+	"globe_with_meridians":       "🌐",
+	"triangular_flag_on_post":    "🚩",
+	"checkered_flag":             "🏁",
+	"white_flag":                 "🏳️",
+	"label":                      "🏷️",
+	"coffee":                     "☕",
+	"beer":                       "🍺",
+	"beers":                      "🍻",
+	"pizza":                      "🍕",
+	"cake":                       "🍰",
+	"birthday":                   "🎂",
+	"cookie":                     "🍪",
+	"apple":                      "🍎",
+	"lemon":                      "🍋",
+
+	// Nature, places and travel
+	"sunny":                  "☀️",
+	"cloud":                  "☁️",
+	"umbrella":               "☔",
+	"snowflake":              "❄️",
+	"rainbow":                "🌈",
+	"ocean":                  "🌊",
+	"earth_americas":         "🌎",
+	"earth_africa":           "🌍",
+	"earth_asia":             "🌏",
+	"crescent_moon":          "🌙",
+	"new_moon":               "🌑",
+	"full_moon":              "🌕",
+	"seedling":               "🌱",
+	"herb":                   "🌿",
+	"four_leaf_clover":       "🍀",
+	"evergreen_tree":         "🌲",
+	"deciduous_tree":         "🌳",
+	"palm_tree":              "🌴",
+	"cactus":                 "🌵",
+	"cherry_blossom":         "🌸",
+	"rose":                   "🌹",
+	"sunflower":              "🌻",
+	"mushroom":               "🍄",
+	"fallen_leaf":            "🍂",
+	"maple_leaf":             "🍁",
+	"dog":                    "🐶",
+	"cat":                    "🐱",
+	"mouse":                  "🐭",
+	"rabbit":                 "🐰",
+	"fox_face":               "🦊",
+	"bear":                   "🐻",
+	"panda_face":             "🐼",
+	"koala":                  "🐨",
+	"tiger":                  "🐯",
+	"lion":                   "🦁",
+	"cow":                    "🐮",
+	"pig":                    "🐷",
+	"frog":                   "🐸",
+	"monkey":                 "🐒",
+	"chicken":                "🐔",
+	"penguin":                "🐧",
+	"bird":                   "🐦",
+	"owl":                    "🦉",
+	"eagle":                  "🦅",
+	"duck":                   "🦆",
+	"unicorn":                "🦄",
+	"bee":                    "🐝",
+	"honeybee":               "🐝",
+	"butterfly":              "🦋",
+	"snail":                  "🐌",
+	"turtle":                 "🐢",
+	"snake":                  "🐍",
+	"dragon":                 "🐉",
+	"whale":                  "🐳",
+	"dolphin":                "🐬",
+	"fish":                   "🐟",
+	"octopus":                "🐙",
+	"crab":                   "🦀",
+	"gopher":                 "🐹",
+	"hamster":                "🐹",
+	"house":                  "🏠",
+	"office":                 "🏢",
+	"hospital":               "🏥",
+	"school":                 "🏫",
+	"factory":                "🏭",
+	"mountain":               "⛰️",
+	"volcano":                "🌋",
+	"camping":                "🏕️",
+	"car":                    "🚗",
+	"red_car":                "🚗",
+	"bus":                    "🚌",
+	"truck":                  "🚚",
+	"bike":                   "🚲",
+	"train":                  "🚆",
+	"airplane":               "✈️",
+	"ship":                   "🚢",
+	"anchor":                 "⚓",
+	"world_map":              "🗺️",
+	"compass":                "🧭",
+	"traffic_light":          "🚥",
+	"vertical_traffic_light": "🚦",
+}
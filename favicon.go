@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+// faviconFiles are served as /favicon.ico, in order of preference
+var faviconFiles = []string{"favicon.ico", "favicon.png"}
+
+// faviconMaxAge is how long browsers may cache the favicon, in seconds, when
+// ASSET_MAX_AGE is not set. Icons rarely change and are requested on every visit.
+const faviconMaxAge = 24 * 60 * 60
+
+// handleFavicon serves favicon.ico, or favicon.png, from the content directory
+// at the site root or from ASSETS_DIR. Browsers ask for it on every visit, so a
+// site without one gets 204 No Content rather than a 404 in the logs each time.
+func (s *Server) handleFavicon(w http.ResponseWriter, r *http.Request) {
+	var dirs []string
+	root := s
+	if len(s.mounts) > 0 {
+		root = nil
+		if m, ok := s.matchMount(r.URL.Path); ok && m.prefix == "/" {
+			root = m.server
+		}
+	}
+	if root != nil {
+		dirs = append(dirs, root.config.ContentDir)
+	}
+	if s.config.AssetsDir != "" {
+		dirs = append(dirs, s.config.AssetsDir)
+	}
+
+	for _, dir := range dirs {
+		for _, name := range faviconFiles {
+			iconPath := filepath.Join(dir, name)
+			if info, err := os.Stat(iconPath); err != nil || info.IsDir() {
+				continue
+			}
+			w.Header().Set("Content-Type", assetContentType(filepath.Ext(name)))
+			cacheControl := fmt.Sprintf("public, max-age=%d", faviconMaxAge)
+			if s.config.AssetMaxAge > 0 {
+				cacheControl = s.config.assetCacheControl()
+			}
+			w.Header().Set("Cache-Control", cacheControl)
+			http.ServeFile(w, r, iconPath)
+			return
+		}
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,271 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// feedPost is a dated markdown page included in feeds
+type feedPost struct {
+	Title       string
+	Description string
+	URL         string
+	ContentHTML string
+	Date        time.Time
+	// Author is the page's author frontmatter, if any
+	Author string
+}
+
+// feedCache holds a generated feed until the content it was built from changes
+type feedCache struct {
+	mu          sync.Mutex
+	fingerprint string
+	data        []byte
+
+	// observe, when set, is told whether each lookup was a hit
+	observe func(hit bool)
+}
+
+// get returns the cached feed if the content fingerprint is unchanged,
+// otherwise it rebuilds the feed and caches the result.
+func (c *feedCache) get(fingerprint string, build func() ([]byte, error)) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	hit := c.data != nil && c.fingerprint == fingerprint
+	if c.observe != nil {
+		c.observe(hit)
+	}
+	if hit {
+		return c.data, nil
+	}
+	data, err := build()
+	if err != nil {
+		return nil, err
+	}
+	c.fingerprint, c.data = fingerprint, data
+	return data, nil
+}
+
+// size returns the number of bytes cached
+func (c *feedCache) size() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.data)
+}
+
+// contentFingerprint summarizes the markdown files in the content directory
+// (a hash of every path and modification time) so caches notice added,
+// edited, removed, renamed and moved files without re-reading them.
+func (s *Server) contentFingerprint() (string, error) {
+	hash := sha256.New()
+	var count int
+	err := s.walkMarkdown(func(path, relPath string, info fs.FileInfo) error {
+		count++
+		fmt.Fprintf(hash, "%s@%d\n", relPath, info.ModTime().UnixNano())
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return strconv.Itoa(count) + "@" + hex.EncodeToString(hash.Sum(nil)[:16]), nil
+}
+
+// combinedFingerprint joins the content fingerprints of every content
+// directory, for caches built from all of them such as the feeds
+func (s *Server) combinedFingerprint() (string, error) {
+	var fingerprint strings.Builder
+	for _, server := range s.contentServers() {
+		fp, err := server.contentFingerprint()
+		if err != nil {
+			return "", err
+		}
+		fingerprint.WriteString(server.urlPrefix + "=" + fp + ";")
+	}
+	return fingerprint.String(), nil
+}
+
+// walkMarkdown calls fn for every markdown file in the content directory,
+// skipping hidden files and directories. relPath uses forward slashes.
+func (s *Server) walkMarkdown(fn func(path, relPath string, info fs.FileInfo) error) error {
+	tree, err := s.contentTree()
+	if err != nil {
+		return err
+	}
+	for _, file := range tree.markdown {
+		if err := fn(file.path, file.relPath, file.info); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// collectPosts scans every content directory for pages with a frontmatter
+// date, returning at most limit posts sorted newest first with URLs under
+// baseURL. Pages without a date are skipped.
+func (s *Server) collectPosts(baseURL string, limit int) ([]feedPost, error) {
+	// Rendering is the expensive part, so it waits until the posts that fall
+	// outside the limit are dropped
+	type candidate struct {
+		post   feedPost
+		server *Server
+		path   string
+		body   []byte
+	}
+	var candidates []candidate
+	for _, server := range s.contentServers() {
+		err := server.walkMarkdown(func(path, relPath string, info fs.FileInfo) error {
+			content, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			meta, body, err := parseFrontmatter(content)
+			if err != nil {
+				slog.Warn("skipping page in feed", "path", relPath, "err", err)
+				return nil
+			}
+			date, ok := metaDate(meta, "date")
+			if !ok || !server.isPublic(meta, relPath) {
+				return nil
+			}
+
+			candidates = append(candidates, candidate{
+				post: feedPost{
+					Title:       server.pageTitle(meta, body),
+					Description: pageDescription(meta, body),
+					URL:         baseURL + server.urlPrefix + server.cleanURL(relPath),
+					Date:        date,
+					Author:      metaString(meta, "author"),
+				},
+				server: server,
+				path:   path,
+				body:   body,
+			})
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].post.Date.After(candidates[j].post.Date)
+	})
+	if limit > 0 && len(candidates) > limit {
+		candidates = candidates[:limit]
+	}
+	posts := make([]feedPost, 0, len(candidates))
+	for _, c := range candidates {
+		c.post.ContentHTML = c.server.markdownToHTML(c.body, c.post.Title, c.path).HTML
+		posts = append(posts, c.post)
+	}
+	return posts, nil
+}
+
+// excerpt shortens text to at most max bytes, cutting at a word boundary and
+// adding an ellipsis when anything was removed
+func excerpt(text string, max int) string {
+	if len(text) <= max {
+		return text
+	}
+	cut := strings.LastIndexByte(text[:max], ' ')
+	if cut <= 0 {
+		cut = max
+	}
+	return strings.ToValidUTF8(strings.TrimRight(text[:cut], " ,.;:"), "") + "…"
+}
+
+// requestBaseURL returns SITE_BASE_URL, or the scheme and host the request was
+// made to when it isn't set, for places where URLs must be absolute
+func (s *Server) requestBaseURL(r *http.Request) string {
+	if s.config.SiteBaseURL != "" {
+		return s.config.SiteBaseURL
+	}
+	scheme, host := "http", r.Host
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	// Behind a proxy terminating TLS, the original scheme and host are forwarded
+	if s.config.TrustProxy {
+		if proto := r.Header.Get("X-Forwarded-Proto"); proto == "http" || proto == "https" {
+			scheme = proto
+		}
+		if forwardedHost := r.Header.Get("X-Forwarded-Host"); forwardedHost != "" {
+			host = forwardedHost
+		}
+	}
+	return scheme + "://" + host
+}
+
+// jsonFeed is a JSON Feed 1.1 document (https://jsonfeed.org/version/1.1)
+type jsonFeed struct {
+	Version     string         `json:"version"`
+	Title       string         `json:"title"`
+	HomePageURL string         `json:"home_page_url,omitempty"`
+	FeedURL     string         `json:"feed_url,omitempty"`
+	Items       []jsonFeedItem `json:"items"`
+}
+
+type jsonFeedItem struct {
+	ID            string `json:"id"`
+	URL           string `json:"url"`
+	Title         string `json:"title"`
+	Summary       string `json:"summary,omitempty"`
+	ContentHTML   string `json:"content_html"`
+	DatePublished string `json:"date_published"`
+}
+
+// handleJSONFeed serves the most recent dated pages as a JSON Feed
+func (s *Server) handleJSONFeed(w http.ResponseWriter, r *http.Request) {
+	fingerprint, err := s.combinedFingerprint()
+	if err != nil {
+		http.Error(w, "Error reading content", http.StatusInternalServerError)
+		return
+	}
+
+	// Links are absolute, so a feed built for another host can't be reused
+	baseURL := s.requestBaseURL(r)
+	data, err := s.jsonFeedCache.get(baseURL+" "+fingerprint, func() ([]byte, error) {
+		posts, err := s.collectPosts(baseURL, s.config.FeedLimit)
+		if err != nil {
+			return nil, err
+		}
+
+		feed := jsonFeed{
+			Version:     "https://jsonfeed.org/version/1.1",
+			Title:       s.config.SiteTitle,
+			HomePageURL: baseURL + "/",
+			FeedURL:     baseURL + "/feed.json",
+			Items:       []jsonFeedItem{},
+		}
+		for _, post := range posts {
+			feed.Items = append(feed.Items, jsonFeedItem{
+				ID:            post.URL,
+				URL:           post.URL,
+				Title:         post.Title,
+				Summary:       post.Description,
+				ContentHTML:   post.ContentHTML,
+				DatePublished: post.Date.Format(time.RFC3339),
+			})
+		}
+		return json.MarshalIndent(feed, "", "  ")
+	})
+	if err != nil {
+		http.Error(w, "Error generating feed", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/feed+json; charset=utf-8")
+	w.Header().Set("Cache-Control", s.config.CacheControl)
+	w.Write(data)
+}
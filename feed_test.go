@@ -0,0 +1,178 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// feedFixtures are two dated posts and an undated page that feeds skip
+var feedFixtures = map[string]string{
+	"index.md":        "# Home\n",
+	"posts/first.md":  "---\ntitle: First Post\ndate: 2024-01-10\ndescription: The first one\n---\n\nHello\n",
+	"posts/second.md": "---\ntitle: Second Post\ndate: 2024-02-20\n---\n\nWorld\n",
+}
+
+func TestJSONFeed(t *testing.T) {
+	s := newTestServer(t, feedFixtures, func(c *Config) {
+		c.SiteTitle = "Test Site"
+		c.SiteBaseURL = "https://example.com"
+	})
+	w := get(s, "/feed.json")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", w.Code)
+	}
+	if got := w.Header().Get("Content-Type"); got != "application/feed+json; charset=utf-8" {
+		t.Errorf("Content-Type = %q", got)
+	}
+
+	// Decode into maps so missing required fields are caught, not zero-filled
+	var feed map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &feed); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	want := map[string]string{
+		"version":       "https://jsonfeed.org/version/1.1",
+		"title":         "Test Site",
+		"home_page_url": "https://example.com/",
+		"feed_url":      "https://example.com/feed.json",
+	}
+	for key, value := range want {
+		if feed[key] != value {
+			t.Errorf("%s = %v, want %q", key, feed[key], value)
+		}
+	}
+
+	items, _ := feed["items"].([]any)
+	wantItems := []map[string]string{
+		{"title": "Second Post", "url": "https://example.com/posts/second", "date_published": "2024-02-20T00:00:00Z"},
+		{"title": "First Post", "url": "https://example.com/posts/first", "date_published": "2024-01-10T00:00:00Z", "summary": "The first one"},
+	}
+	if len(items) != len(wantItems) {
+		t.Fatalf("got %d items, want %d", len(items), len(wantItems))
+	}
+	for i, wantItem := range wantItems {
+		item := items[i].(map[string]any)
+		for _, required := range []string{"id", "url", "title", "content_html", "date_published"} {
+			if _, ok := item[required]; !ok {
+				t.Errorf("item %d has no %s", i, required)
+			}
+		}
+		if item["id"] != item["url"] {
+			t.Errorf("item %d id = %v, want its url", i, item["id"])
+		}
+		for key, value := range wantItem {
+			if item[key] != value {
+				t.Errorf("item %d %s = %v, want %q", i, key, item[key], value)
+			}
+		}
+	}
+}
+
+func TestJSONFeedLimit(t *testing.T) {
+	s := newTestServer(t, feedFixtures, func(c *Config) { c.FeedLimit = 1 })
+	var feed jsonFeed
+	if err := json.Unmarshal(get(s, "/feed.json").Body.Bytes(), &feed); err != nil {
+		t.Fatal(err)
+	}
+	if len(feed.Items) != 1 || feed.Items[0].Title != "Second Post" {
+		t.Errorf("items = %+v, want only the newest post", feed.Items)
+	}
+}
+
+func TestJSONFeedCacheInvalidation(t *testing.T) {
+	s := newTestServer(t, feedFixtures, nil)
+	decode := func() jsonFeed {
+		var feed jsonFeed
+		if err := json.Unmarshal(get(s, "/feed.json").Body.Bytes(), &feed); err != nil {
+			t.Fatal(err)
+		}
+		return feed
+	}
+	if n := len(decode().Items); n != 2 {
+		t.Fatalf("got %d items, want 2", n)
+	}
+	writeFiles(t, s.config.ContentDir, map[string]string{
+		"posts/third.md": "---\ntitle: Third Post\ndate: 2024-03-01\n---\n",
+	})
+	feed := decode()
+	if len(feed.Items) != 3 || feed.Items[0].Title != "Third Post" {
+		t.Errorf("items after adding a post = %+v, want the new post first", feed.Items)
+	}
+}
+
+func TestContentFingerprint(t *testing.T) {
+	older, newer := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		change func(dir string) error
+	}{
+		{"rename", func(dir string) error {
+			return os.Rename(filepath.Join(dir, "posts/first.md"), filepath.Join(dir, "posts/renamed.md"))
+		}},
+		{"move", func(dir string) error {
+			if err := os.Mkdir(filepath.Join(dir, "archive"), 0o755); err != nil {
+				return err
+			}
+			return os.Rename(filepath.Join(dir, "posts/first.md"), filepath.Join(dir, "archive/first.md"))
+		}},
+		{"swapped modification times", func(dir string) error {
+			if err := os.Chtimes(filepath.Join(dir, "posts/first.md"), newer, newer); err != nil {
+				return err
+			}
+			return os.Chtimes(filepath.Join(dir, "posts/second.md"), older, older)
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServer(t, feedFixtures, nil)
+			dir := s.config.ContentDir
+			for name, modTime := range map[string]time.Time{"index.md": older, "posts/first.md": older, "posts/second.md": newer} {
+				if err := os.Chtimes(filepath.Join(dir, name), modTime, modTime); err != nil {
+					t.Fatal(err)
+				}
+			}
+			before, err := s.contentFingerprint()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := tt.change(dir); err != nil {
+				t.Fatal(err)
+			}
+			after, err := s.contentFingerprint()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if before == after {
+				t.Errorf("fingerprint %q unchanged", after)
+			}
+		})
+	}
+}
+
+func TestJSONFeedMounts(t *testing.T) {
+	docs, blog := t.TempDir(), t.TempDir()
+	writeFiles(t, docs, map[string]string{"guide.md": "---\ntitle: Guide\ndate: 2024-01-01\n---\n"})
+	writeFiles(t, blog, map[string]string{"hello.md": "---\ntitle: Hello\ndate: 2024-05-01\n---\n"})
+	s := newTestServer(t, nil, func(c *Config) {
+		c.SiteBaseURL = "https://example.com"
+		c.Mounts = []Mount{{Prefix: "/docs", Dir: docs}, {Prefix: "/blog", Dir: blog}}
+	})
+
+	var feed jsonFeed
+	if err := json.Unmarshal(get(s, "/feed.json").Body.Bytes(), &feed); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"https://example.com/blog/hello", "https://example.com/docs/guide"}
+	if len(feed.Items) != len(want) {
+		t.Fatalf("got %d items, want %d", len(feed.Items), len(want))
+	}
+	for i, url := range want {
+		if feed.Items[i].URL != url {
+			t.Errorf("item %d url = %q, want %q", i, feed.Items[i].URL, url)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FRAME_POLICY values other than an allowlist of origins
+const (
+	framePolicyDeny       = "deny"
+	framePolicySameOrigin = "sameorigin"
+)
+
+// frameAncestors returns the sources of the frame-ancestors directive:
+// 'none' for FRAME_POLICY=deny, 'self' for sameorigin, the allowlisted
+// origins, or CSP_FRAME_ANCESTORS when FRAME_POLICY isn't set
+func (c Config) frameAncestors() string {
+	switch strings.ToLower(c.FramePolicy) {
+	case "":
+		return c.CSPFrameAncestors
+	case framePolicyDeny:
+		return "'none'"
+	case framePolicySameOrigin:
+		return "'self'"
+	}
+	return c.FramePolicy
+}
+
+// frameOptions returns the X-Frame-Options header for FRAME_POLICY, or ""
+// when framing is allowed from anywhere or from an allowlist, which the header
+// can't express
+func (c Config) frameOptions() string {
+	switch strings.ToLower(c.FramePolicy) {
+	case framePolicyDeny:
+		return "DENY"
+	case framePolicySameOrigin:
+		return "SAMEORIGIN"
+	}
+	return ""
+}
+
+// validateFramePolicy checks that FRAME_POLICY is deny, sameorigin or a
+// space-separated list of origins such as https://example.com
+func validateFramePolicy(policy string) error {
+	switch strings.ToLower(policy) {
+	case "", framePolicyDeny, framePolicySameOrigin:
+		return nil
+	}
+	for _, origin := range strings.Fields(policy) {
+		if origin == "'self'" {
+			continue
+		}
+		if strings.ContainsAny(origin, ";,'") || !strings.Contains(origin, ":") && !strings.Contains(origin, ".") {
+			return fmt.Errorf("invalid FRAME_POLICY %q: must be deny, sameorigin or a space-separated list of origins", policy)
+		}
+	}
+	return nil
+}
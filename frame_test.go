@@ -0,0 +1,69 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFramePolicy(t *testing.T) {
+	tests := []struct {
+		name           string
+		policy         string
+		frameOptions   string
+		frameAncestors string
+	}{
+		{"default", "", "", "frame-ancestors *"},
+		{"deny", "deny", "DENY", "frame-ancestors 'none'"},
+		{"sameorigin", "sameorigin", "SAMEORIGIN", "frame-ancestors 'self'"},
+		{"case insensitive", "SameOrigin", "SAMEORIGIN", "frame-ancestors 'self'"},
+		{"allowlist", "https://a.example.com https://b.example.com", "", "frame-ancestors https://a.example.com https://b.example.com"},
+		{"allowlist with self", "'self' https://a.example.com", "", "frame-ancestors 'self' https://a.example.com"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServer(t, map[string]string{"index.md": "# Home\n"}, func(c *Config) { c.FramePolicy = tt.policy })
+			w := get(s, "/")
+			if got := w.Header().Get("X-Frame-Options"); got != tt.frameOptions {
+				t.Errorf("X-Frame-Options = %q, want %q", got, tt.frameOptions)
+			}
+			if policy := w.Header().Get("Content-Security-Policy"); !strings.Contains(policy, tt.frameAncestors+";") {
+				t.Errorf("Content-Security-Policy = %q, want %q", policy, tt.frameAncestors)
+			}
+		})
+	}
+}
+
+func TestFramePolicyOverridesFrameAncestors(t *testing.T) {
+	s := newTestServer(t, map[string]string{"index.md": "# Home\n"}, func(c *Config) {
+		c.CSPFrameAncestors = "https://old.example.com"
+		c.FramePolicy = "deny"
+	})
+	if policy := get(s, "/").Header().Get("Content-Security-Policy"); !strings.Contains(policy, "frame-ancestors 'none';") {
+		t.Errorf("Content-Security-Policy = %q, want FRAME_POLICY to win over CSP_FRAME_ANCESTORS", policy)
+	}
+}
+
+func TestValidateFramePolicy(t *testing.T) {
+	tests := []struct {
+		policy string
+		ok     bool
+	}{
+		{"", true},
+		{"deny", true},
+		{"SAMEORIGIN", true},
+		{"https://a.example.com https://b.example.com", true},
+		{"'self' https://a.example.com", true},
+		{"*.example.com", true},
+		{"allow-from", false},
+		{"https://a.example.com; script-src *", false},
+		{"https://a.example.com,https://b.example.com", false},
+		{"'none' https://a.example.com", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			if err := validateFramePolicy(tt.policy); (err == nil) != tt.ok {
+				t.Errorf("validateFramePolicy(%q) = %v, want ok %v", tt.policy, err, tt.ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Frontmatter delimiters: "---" opens and closes a YAML block, "+++" a TOML block
+var (
+	yamlFrontmatterDelimiter = []byte("---")
+	tomlFrontmatterDelimiter = []byte("+++")
+)
+
+// parseFrontmatter splits a leading frontmatter block from the markdown body.
+// The format is detected from the first line: "---" for YAML, "+++" for TOML.
+// Documents without frontmatter are returned unchanged with a nil map, as is
+// the whole document when the frontmatter is invalid, along with an error.
+func parseFrontmatter(content []byte) (map[string]interface{}, []byte, error) {
+	firstLine, rest, found := bytes.Cut(content, []byte("\n"))
+	if !found {
+		return nil, content, nil
+	}
+	delimiter := bytes.TrimSpace(firstLine)
+	isTOML := bytes.Equal(delimiter, tomlFrontmatterDelimiter)
+	if !isTOML && !bytes.Equal(delimiter, yamlFrontmatterDelimiter) {
+		return nil, content, nil
+	}
+
+	// Find the closing delimiter line
+	var block []byte
+	for len(rest) > 0 {
+		line, remaining, _ := bytes.Cut(rest, []byte("\n"))
+		if bytes.Equal(bytes.TrimSpace(line), delimiter) {
+			meta := map[string]interface{}{}
+			if isTOML {
+				if err := toml.Unmarshal(block, &meta); err != nil {
+					return nil, content, fmt.Errorf("invalid TOML frontmatter: %w", err)
+				}
+			} else if err := yaml.Unmarshal(block, &meta); err != nil {
+				return nil, content, fmt.Errorf("invalid YAML frontmatter: %w", err)
+			}
+			return meta, remaining, nil
+		}
+		block = append(block, line...)
+		block = append(block, '\n')
+		rest = remaining
+	}
+
+	// A "---" without a closing delimiter is not frontmatter (e.g. a leading
+	// horizontal rule), but "+++" has no other meaning in markdown
+	if isTOML {
+		return nil, content, fmt.Errorf("TOML frontmatter opened with +++ is never closed")
+	}
+	return nil, content, nil
+}
+
+// metaString returns a string frontmatter value, or "" if it is missing or not a string
+func metaString(meta map[string]interface{}, key string) string {
+	if value, ok := meta[key].(string); ok {
+		return value
+	}
+	return ""
+}
+
+// metaBool returns a boolean frontmatter value, or false if it is missing or not a boolean
+func metaBool(meta map[string]interface{}, key string) bool {
+	value, _ := meta[key].(bool)
+	return value
+}
+
+// metaInt returns an integer frontmatter value and whether it was present.
+// YAML and TOML decode integers to different types, so both are accepted,
+// as are whole floats such as `410.0`.
+func metaInt(meta map[string]interface{}, key string) (int, bool) {
+	switch value := meta[key].(type) {
+	case int:
+		return value, true
+	case int64:
+		return int(value), true
+	case float64:
+		if value == math.Trunc(value) {
+			return int(value), true
+		}
+	}
+	return 0, false
+}
+
+// frontmatterDateLayouts are the date formats accepted in frontmatter
+var frontmatterDateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+}
+
+// metaDate returns a frontmatter date value. YAML timestamps may arrive either
+// as time.Time or as strings depending on how they were written.
+func metaDate(meta map[string]interface{}, key string) (time.Time, bool) {
+	switch value := meta[key].(type) {
+	case time.Time:
+		return value, true
+	case string:
+		for _, layout := range frontmatterDateLayouts {
+			if date, err := time.Parse(layout, value); err == nil {
+				return date, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// pageStatus returns the HTTP status a page should be served with. Pages can
+// override the default with a `status` frontmatter key, e.g. `status: 410` for
+// removed content. Only 2xx codes that carry a body and 4xx/5xx codes are
+// accepted; anything else is logged and ignored.
+func pageStatus(meta map[string]interface{}, filePath string, def int) int {
+	value, ok := meta["status"]
+	if !ok {
+		return def
+	}
+
+	status, _ := metaInt(meta, "status")
+	if v, isString := value.(string); isString {
+		status, _ = strconv.Atoi(v)
+	}
+
+	switch {
+	case status == http.StatusNoContent || status == http.StatusResetContent:
+	case status >= 200 && status < 300, status >= 400 && status < 600:
+		return status
+	}
+	slog.Warn("ignoring invalid status", "path", filePath, "status", value)
+	return def
+}
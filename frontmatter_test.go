@@ -0,0 +1,63 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestPageStatus(t *testing.T) {
+	tests := []struct {
+		name string
+		meta map[string]interface{}
+		want int
+	}{
+		{"no status", nil, http.StatusOK},
+		{"int", map[string]interface{}{"status": 410}, http.StatusGone},
+		{"int64", map[string]interface{}{"status": int64(451)}, http.StatusUnavailableForLegalReasons},
+		{"float64", map[string]interface{}{"status": 503.0}, http.StatusServiceUnavailable},
+		{"string", map[string]interface{}{"status": "404"}, http.StatusNotFound},
+		{"other 2xx", map[string]interface{}{"status": 203}, http.StatusNonAuthoritativeInfo},
+		{"no content", map[string]interface{}{"status": 204}, http.StatusOK},
+		{"redirect", map[string]interface{}{"status": 301}, http.StatusOK},
+		{"out of range", map[string]interface{}{"status": 999}, http.StatusOK},
+		{"not a number", map[string]interface{}{"status": "gone"}, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := pageStatus(tt.meta, "page.md", http.StatusOK); got != tt.want {
+				t.Errorf("pageStatus = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPageStatusResponse(t *testing.T) {
+	s := newTestServer(t, map[string]string{
+		"gone.md":  "---\ntitle: Moved Away\nstatus: 410\n---\n\nThis page was removed.\n",
+		"legal.md": "+++\ntitle = \"Blocked\"\nstatus = 451\n+++\n\nNot available here.\n",
+	}, nil)
+
+	tests := []struct {
+		path   string
+		status int
+		title  string
+	}{
+		{"/gone", http.StatusGone, "Moved Away"},
+		{"/legal", http.StatusUnavailableForLegalReasons, "Blocked"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			w := get(s, tt.path)
+			if w.Code != tt.status {
+				t.Errorf("status = %d, want %d", w.Code, tt.status)
+			}
+			if got := w.Header().Get("Content-Type"); !strings.HasPrefix(got, "text/html") {
+				t.Errorf("Content-Type = %q, want the rendered page", got)
+			}
+			if !strings.Contains(w.Body.String(), "<title>"+tt.title) {
+				t.Errorf("body has no %q title:\n%s", tt.title, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/gomarkdown/markdown/ast"
+)
+
+// Single H1 enforcement modes (SINGLE_H1)
+const (
+	singleH1Warn   = "warn"   // log pages with missing or multiple H1s
+	singleH1Demote = "demote" // additionally synthesize a missing H1 and demote extras to H2
+)
+
+// enforceSingleH1 checks that the document has exactly one H1. Depending on the
+// configured mode it synthesizes a missing H1 from title and demotes extra H1s to H2.
+func (s *Server) enforceSingleH1(doc ast.Node, title, filePath string) {
+	var h1s []*ast.Heading
+	ast.WalkFunc(doc, func(node ast.Node, entering bool) ast.WalkStatus {
+		if heading, ok := node.(*ast.Heading); ok && entering && heading.Level == 1 {
+			h1s = append(h1s, heading)
+		}
+		return ast.GoToNext
+	})
+
+	switch {
+	case len(h1s) == 0:
+		slog.Warn("page has no H1 heading", "path", filePath)
+		if s.config.SingleH1 == singleH1Demote {
+			heading := &ast.Heading{Level: 1}
+			ast.AppendChild(heading, &ast.Text{Leaf: ast.Leaf{Literal: []byte(title)}})
+			heading.SetParent(doc)
+			doc.SetChildren(append([]ast.Node{heading}, doc.GetChildren()...))
+		}
+	case len(h1s) > 1:
+		slog.Warn("page has several H1 headings", "path", filePath, "count", len(h1s))
+		if s.config.SingleH1 == singleH1Demote {
+			for _, heading := range h1s[1:] {
+				heading.Level = 2
+			}
+		}
+	}
+}
+
+// titleFromFilename derives a human-friendly title from a markdown file path,
+// relative to the content directory, e.g. "docs/getting-started.md" becomes
+// "Getting Started". The default document takes the name of its directory.
+func (s *Server) titleFromFilename(path string) string {
+	name := strings.TrimSuffix(filepath.Base(path), ".md")
+	if name == strings.TrimSuffix(s.indexFile, ".md") {
+		name = filepath.Base(filepath.Dir(path))
+	}
+	words := strings.FieldsFunc(name, func(r rune) bool {
+		return r == '-' || r == '_' || r == ' ' || r == '.' || r == '/'
+	})
+	if len(words) == 0 {
+		return defaultTitle
+	}
+	for i, word := range words {
+		first, size := utf8.DecodeRuneInString(word)
+		words[i] = string(unicode.ToTitle(first)) + word[size:]
+	}
+	return strings.Join(words, " ")
+}
+
+// renderHeadingAnchor writes a permalink to the heading's own ID, placed just
+// before the closing tag. It is a plain link, so it can be reached with the
+// keyboard; the stylesheet hides it until the heading is hovered or it has focus.
+func renderHeadingAnchor(w io.Writer, heading *ast.Heading) {
+	fmt.Fprintf(w, ` <a class="heading-anchor" href="#%s" aria-label="Link to this section">#</a>`, html.EscapeString(heading.HeadingID))
+}
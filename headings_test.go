@@ -0,0 +1,124 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/gomarkdown/markdown/ast"
+)
+
+// heading returns a heading node of the given level containing text
+func heading(level int, text string) *ast.Heading {
+	h := &ast.Heading{Level: level}
+	ast.AppendChild(h, &ast.Text{Leaf: ast.Leaf{Literal: []byte(text)}})
+	return h
+}
+
+// document returns a document node with the given children
+func document(children ...ast.Node) *ast.Document {
+	doc := &ast.Document{}
+	for _, child := range children {
+		ast.AppendChild(doc, child)
+	}
+	return doc
+}
+
+// headingLevels lists the level and text of every heading in doc
+func headingLevels(doc ast.Node) []string {
+	var levels []string
+	ast.WalkFunc(doc, func(node ast.Node, entering bool) ast.WalkStatus {
+		if h, ok := node.(*ast.Heading); ok && entering {
+			text := ""
+			if first := ast.GetFirstChild(h); first != nil {
+				text = string(first.AsLeaf().Literal)
+			}
+			levels = append(levels, fmt.Sprintf("%d %s", h.Level, text))
+		}
+		return ast.GoToNext
+	})
+	return levels
+}
+
+func TestEnforceSingleH1(t *testing.T) {
+	tests := []struct {
+		name string
+		mode string
+		doc  func() *ast.Document
+		want []string
+	}{
+		{"single H1 untouched", singleH1Demote, func() *ast.Document {
+			return document(heading(1, "Title"), heading(2, "Section"))
+		}, []string{"1 Title", "2 Section"}},
+		{"warn leaves extra H1s", singleH1Warn, func() *ast.Document {
+			return document(heading(1, "One"), heading(1, "Two"))
+		}, []string{"1 One", "1 Two"}},
+		{"warn leaves missing H1", singleH1Warn, func() *ast.Document {
+			return document(heading(2, "Section"))
+		}, []string{"2 Section"}},
+		{"demote extra H1s", singleH1Demote, func() *ast.Document {
+			return document(heading(1, "One"), heading(1, "Two"), heading(1, "Three"))
+		}, []string{"1 One", "2 Two", "2 Three"}},
+		{"demote synthesizes missing H1", singleH1Demote, func() *ast.Document {
+			return document(heading(2, "Section"))
+		}, []string{"1 Page Title", "2 Section"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Server{config: Config{SingleH1: tt.mode}}
+			doc := tt.doc()
+			s.enforceSingleH1(doc, "Page Title", "page.md")
+			got := headingLevels(doc)
+			if len(got) != len(tt.want) {
+				t.Fatalf("headings = %q, want %q", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("headings = %q, want %q", got, tt.want)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestTitleFromFilename(t *testing.T) {
+	tests := []struct {
+		indexFile string
+		path      string
+		want      string
+	}{
+		{"index.md", "getting-started.md", "Getting Started"},
+		{"index.md", "docs/api_reference.md", "Api Reference"},
+		{"index.md", "docs/index.md", "Docs"},
+		{"index.md", "über-uns.md", "Über Uns"},
+		{"index.md", "docs/README.md", "README"},
+		{"README.md", "docs/README.md", "Docs"},
+		{"README.md", "docs/index.md", "Index"},
+		{"index.md", "---.md", defaultTitle},
+	}
+	for _, tt := range tests {
+		s := &Server{indexFile: tt.indexFile}
+		if got := s.titleFromFilename(tt.path); got != tt.want {
+			t.Errorf("titleFromFilename(%q) with %s = %q, want %q", tt.path, tt.indexFile, got, tt.want)
+		}
+	}
+}
+
+func TestExtractTitle(t *testing.T) {
+	tests := []struct {
+		content string
+		want    string
+	}{
+		{"# Hello\n\nText", "Hello"},
+		{"Intro\n\n  # Indented\n", "Indented"},
+		{"## Section only\n", defaultTitle},
+		{"#NoSpace\n", defaultTitle},
+		{"", defaultTitle},
+	}
+	s := &Server{}
+	for _, tt := range tests {
+		if got := s.extractTitle(tt.content); got != tt.want {
+			t.Errorf("extractTitle(%q) = %q, want %q", tt.content, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+)
+
+// Probe endpoints for container orchestration. They are registered as exact
+// routes, so they take precedence over healthz.md or readyz.md in the content
+// directory, and they never require authentication.
+const (
+	healthzPath = "/healthz"
+	readyzPath  = "/readyz"
+)
+
+// healthStatus is the response body of the probes:
+//
+//	{"status": "ok"}
+//	{"status": "unavailable", "error": "content directory ./content is not readable"}
+type healthStatus struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// handleHealthz reports that the process is up and can read its content
+// (liveness), so a pod whose volume went away is restarted
+func (s *Server) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	writeHealth(w, s.checkContentDirs())
+}
+
+// handleReadyz reports whether every content directory is readable, the last
+// reload of TEMPLATE_FILE succeeded and the search indexes have been built
+// (readiness)
+func (s *Server) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	err := s.checkContentDirs()
+	if err == nil {
+		err = s.templates.loadError()
+	}
+	if err == nil {
+		for _, server := range s.contentServers() {
+			if !server.search.ready() {
+				err = fmt.Errorf("search index for %s is not built yet", server.config.ContentDir)
+				break
+			}
+		}
+	}
+	writeHealth(w, err)
+}
+
+// checkContentDirs returns an error if any content directory is missing or
+// can't be read
+func (s *Server) checkContentDirs() error {
+	for _, server := range s.contentServers() {
+		if _, err := os.ReadDir(server.config.ContentDir); err != nil {
+			return fmt.Errorf("content directory %s is not readable", server.config.ContentDir)
+		}
+	}
+	return nil
+}
+
+// writeHealth writes a probe response: 200 without an error, 503 with one
+func writeHealth(w http.ResponseWriter, err error) {
+	status, body := http.StatusOK, healthStatus{Status: "ok"}
+	if err != nil {
+		status, body = http.StatusServiceUnavailable, healthStatus{Status: "unavailable", Error: err.Error()}
+	}
+	data, _ := json.Marshal(body)
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	w.Write(append(data, '\n'))
+}
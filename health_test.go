@@ -0,0 +1,75 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"testing"
+)
+
+func TestProbes(t *testing.T) {
+	files := map[string]string{"index.md": "# Home\n", "healthz.md": "# Not the probe\n"}
+	tests := []struct {
+		name      string
+		path      string
+		unindexed bool
+		setup     func(t *testing.T, s *Server)
+		status    int
+	}{
+		{"live", healthzPath, false, nil, http.StatusOK},
+		{"ready", readyzPath, false, nil, http.StatusOK},
+		{"live without content", healthzPath, false, removeContent, http.StatusServiceUnavailable},
+		{"ready without content", readyzPath, false, removeContent, http.StatusServiceUnavailable},
+		{"live before search index", healthzPath, true, nil, http.StatusOK},
+		{"ready before search index", readyzPath, true, nil, http.StatusServiceUnavailable},
+		{"ready after a failed template reload", readyzPath, false, func(t *testing.T, s *Server) {
+			replaceFile(t, s.config.TemplateFile, "{{.Title")
+			if err := s.templates.load(); err == nil {
+				t.Fatal("broken template loaded")
+			}
+		}, http.StatusServiceUnavailable},
+		{"live after a failed template reload", healthzPath, false, func(t *testing.T, s *Server) {
+			replaceFile(t, s.config.TemplateFile, "{{.Title")
+			s.templates.load()
+		}, http.StatusOK},
+		{"no authentication", readyzPath, false, func(t *testing.T, s *Server) {
+			s.config.BasicAuthUser, s.config.BasicAuthPass = "admin", "secret"
+		}, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			template := writeTemplate(t, "<title>{{.Title}}</title>")
+			s := newTestServer(t, files, func(c *Config) { c.TemplateFile = template })
+			if !tt.unindexed {
+				if err := s.buildSearchIndex(); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if tt.setup != nil {
+				tt.setup(t, s)
+			}
+
+			w := get(s, tt.path)
+			if w.Code != tt.status {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.status, w.Body.String())
+			}
+			if got := w.Header().Get("Cache-Control"); got != "no-store" {
+				t.Errorf("Cache-Control = %q, want no-store", got)
+			}
+			var body healthStatus
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatalf("invalid JSON %q: %v", w.Body.String(), err)
+			}
+			if ok := tt.status == http.StatusOK; ok != (body.Status == "ok") || ok != (body.Error == "") {
+				t.Errorf("body = %+v for status %d", body, w.Code)
+			}
+		})
+	}
+}
+
+// removeContent deletes the content directory from under a running server
+func removeContent(t *testing.T, s *Server) {
+	if err := os.RemoveAll(s.config.ContentDir); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"github.com/alecthomas/chroma/v2"
+	chromahtml "github.com/alecthomas/chroma/v2/formatters/html"
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/alecthomas/chroma/v2/styles"
+	"github.com/gomarkdown/markdown/ast"
+)
+
+// defaultHighlightTheme is the Chroma style used for code blocks
+const defaultHighlightTheme = "github"
+
+// highlightFormatter emits highlighted code with CSS classes, styled by the
+// stylesheet served from /__highlight.css
+var highlightFormatter = chromahtml.New(chromahtml.WithClasses(true))
+
+// renderHighlightedCode writes a fenced code block with syntax highlighting.
+// It reports false, without writing anything, when the block has no language
+// or the language is unknown, so the block is rendered as plain code.
+func (s *Server) renderHighlightedCode(w io.Writer, block *ast.CodeBlock) bool {
+	fields := bytes.Fields(block.Info)
+	if len(fields) == 0 {
+		return false
+	}
+	lexer := lexers.Get(string(fields[0]))
+	if lexer == nil {
+		return false
+	}
+
+	iterator, err := chroma.Coalesce(lexer).Tokenise(nil, string(block.Literal))
+	if err != nil {
+		slog.Warn("failed to highlight code block", "language", string(fields[0]), "err", err)
+		return false
+	}
+	var buf bytes.Buffer
+	if err := highlightFormatter.Format(&buf, styles.Get(s.config.HighlightTheme), iterator); err != nil {
+		slog.Warn("failed to highlight code block", "language", string(fields[0]), "err", err)
+		return false
+	}
+	w.Write(buf.Bytes())
+	return true
+}
+
+// handleHighlightCSS serves the stylesheet for the configured HIGHLIGHT_THEME
+func (s *Server) handleHighlightCSS(w http.ResponseWriter, r *http.Request) {
+	var buf bytes.Buffer
+	if err := highlightFormatter.WriteCSS(&buf, styles.Get(s.config.HighlightTheme)); err != nil {
+		http.Error(w, "Error generating stylesheet", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/css; charset=utf-8")
+	w.Header().Set("Cache-Control", s.config.CacheControl)
+	w.Write(buf.Bytes())
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// htpasswdFile holds the users of BASIC_AUTH_FILE, an Apache htpasswd file,
+// and their password hashes
+type htpasswdFile struct {
+	hashes map[string]string
+	// dummy is compared against for unknown users, so they take as long to
+	// reject as wrong passwords and user names can't be probed
+	dummy []byte
+}
+
+// loadHtpasswd reads an htpasswd file with one user:hash entry per line. Only
+// bcrypt (htpasswd -B) and SHA-1 (htpasswd -s) hashes are supported; the
+// default MD5 variant is Apache-specific and is rejected.
+func loadHtpasswd(path string) (*htpasswdFile, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read BASIC_AUTH_FILE: %w", err)
+	}
+	defer f.Close()
+
+	hashes := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		entry := strings.TrimSpace(scanner.Text())
+		if entry == "" || strings.HasPrefix(entry, "#") {
+			continue
+		}
+		user, hash, ok := strings.Cut(entry, ":")
+		if !ok || user == "" {
+			return nil, fmt.Errorf("%s:%d: expected user:hash", path, line)
+		}
+		if !isBcryptHash(hash) && !strings.HasPrefix(hash, "{SHA}") {
+			return nil, fmt.Errorf("%s:%d: unsupported hash for %s, use bcrypt (htpasswd -B) or SHA-1 (htpasswd -s)", path, line, user)
+		}
+		hashes[user] = hash
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read BASIC_AUTH_FILE: %w", err)
+	}
+	if len(hashes) == 0 {
+		return nil, fmt.Errorf("%s has no users", path)
+	}
+
+	dummy, err := bcrypt.GenerateFromPassword([]byte("dummy"), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+	return &htpasswdFile{hashes: hashes, dummy: dummy}, nil
+}
+
+// verify reports whether pass is the password of user
+func (h *htpasswdFile) verify(user, pass string) bool {
+	hash, ok := h.hashes[user]
+	if !ok {
+		bcrypt.CompareHashAndPassword(h.dummy, []byte(pass))
+		return false
+	}
+	if sha, ok := strings.CutPrefix(hash, "{SHA}"); ok {
+		sum := sha1.Sum([]byte(pass))
+		return subtle.ConstantTimeCompare([]byte(base64.StdEncoding.EncodeToString(sum[:])), []byte(sha)) == 1
+	}
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(pass)) == nil
+}
+
+// isBcryptHash reports whether hash is in one of the bcrypt formats htpasswd writes
+func isBcryptHash(hash string) bool {
+	for _, prefix := range []string{"$2a$", "$2b$", "$2y$"} {
+		if strings.HasPrefix(hash, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	"github.com/quic-go/quic-go/http3"
+)
+
+// http3Listeners serve handler over HTTPS on TCP and HTTP/3 on UDP at the same
+// address. TCP responses advertise HTTP/3 through the Alt-Svc header.
+func (s *Server) http3Listeners(addr string, handler http.Handler) []listener {
+	quicServer := &http3.Server{
+		Addr:    addr,
+		Handler: handler,
+	}
+
+	tlsServer := s.newHTTPServer(addr, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := quicServer.SetQuicHeaders(w.Header()); err != nil {
+			slog.Warn("failed to set Alt-Svc header", "err", err)
+		}
+		handler.ServeHTTP(w, r)
+	}))
+
+	return []listener{
+		httpListener(tlsServer, func() error {
+			return tlsServer.ListenAndServeTLS(s.config.TLSCertFile, s.config.TLSKeyFile)
+		}),
+		{
+			serve: func() error {
+				return quicServer.ListenAndServeTLS(s.config.TLSCertFile, s.config.TLSKeyFile)
+			},
+			// QUIC connections are closed immediately; clients retry over TCP
+			shutdown: func(context.Context) error { return quicServer.Close() },
+		},
+	}
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeTestCert writes a self-signed certificate for 127.0.0.1 and its key
+// to a temporary directory and returns their paths
+func writeTestCert(t *testing.T) (certFile, keyFile string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "127.0.0.1"},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	certFile, keyFile = filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return certFile, keyFile
+}
+
+// freePort returns a TCP port on 127.0.0.1 that nothing is listening on
+func freePort(t *testing.T) string {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	return strconv.Itoa(l.Addr().(*net.TCPAddr).Port)
+}
+
+// runTestServer starts s with Run and stops it when the test ends
+func runTestServer(t *testing.T, s *Server) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- s.Run(ctx) }()
+	t.Cleanup(func() {
+		cancel()
+		if err := <-done; err != nil {
+			t.Errorf("Run: %v", err)
+		}
+	})
+}
+
+// insecureClient trusts any certificate, for the self-signed test certificate
+var insecureClient = &http.Client{
+	Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}},
+	Timeout:   5 * time.Second,
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
+
+// waitForHeader requests url until the response has header, or fails the
+// test after a few seconds. Listeners start concurrently, so the first
+// requests may be refused or answered before HTTP/3 is up.
+func waitForHeader(t *testing.T, url, header string) string {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		resp, err := insecureClient.Get(url)
+		if err == nil {
+			resp.Body.Close()
+			if value := resp.Header.Get(header); value != "" {
+				return value
+			}
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("no %s header from %s (last error: %v)", header, url, err)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func TestHTTP3AltSvc(t *testing.T) {
+	certFile, keyFile := writeTestCert(t)
+	port := freePort(t)
+	s := newTestServer(t, map[string]string{"index.md": "# Home\n"}, func(c *Config) {
+		c.BindAddr = "127.0.0.1"
+		c.Port = port
+		c.TLSCertFile, c.TLSKeyFile = certFile, keyFile
+		c.HTTP3 = true
+	})
+	runTestServer(t, s)
+
+	altSvc := waitForHeader(t, "https://127.0.0.1:"+port+"/", "Alt-Svc")
+	if !strings.Contains(altSvc, `h3=":`+port+`"`) {
+		t.Errorf("Alt-Svc = %q, want h3 on port %s", altSvc, port)
+	}
+}
+
+func TestHTTPSWithoutHTTP3HasNoAltSvc(t *testing.T) {
+	certFile, keyFile := writeTestCert(t)
+	port := freePort(t)
+	s := newTestServer(t, map[string]string{"index.md": "# Home\n"}, func(c *Config) {
+		c.BindAddr = "127.0.0.1"
+		c.Port = port
+		c.TLSCertFile, c.TLSKeyFile = certFile, keyFile
+	})
+	runTestServer(t, s)
+
+	// Wait for the listener through a header every response has
+	waitForHeader(t, "https://127.0.0.1:"+port+"/", "Content-Type")
+	resp, err := insecureClient.Get("https://127.0.0.1:" + port + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if altSvc := resp.Header.Get("Alt-Svc"); altSvc != "" {
+		t.Errorf("Alt-Svc = %q without HTTP3, want none", altSvc)
+	}
+}
+
+func TestHTTP3RequiresTLS(t *testing.T) {
+	config := defaultConfig()
+	config.HTTP3 = true
+	if err := config.validate(); err == nil {
+		t.Error("validate accepted HTTP3 without a certificate")
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"regexp"
+)
+
+// maxIncludeDepth limits how deeply included files can include other files
+const maxIncludeDepth = 10
+
+// includeDirective matches {{include "path/to/file.md"}}
+var includeDirective = regexp.MustCompile(`\{\{\s*include\s+"([^"]+)"\s*\}\}`)
+
+// expandIncludes replaces include directives in md with the contents of the
+// referenced files, resolved against the content directory. Included files are
+// expanded recursively. Directives inside fenced code blocks are left as they
+// are so they can be documented. Problems such as missing files or include
+// cycles are rendered as an inline error instead of failing the page.
+func (s *Server) expandIncludes(md []byte, filePath string) []byte {
+	absPath, err := filepath.Abs(filePath)
+	if err != nil {
+		absPath = filePath
+	}
+	return s.expandIncludesFrom(md, []string{absPath})
+}
+
+// expandIncludesFrom expands includes in md, where stack holds the files being
+// expanded with md's own file last
+func (s *Server) expandIncludesFrom(md []byte, stack []string) []byte {
+	if !includeDirective.Match(md) {
+		return md
+	}
+
+	var out bytes.Buffer
+	inFence := false
+	for _, line := range bytes.SplitAfter(md, []byte("\n")) {
+		trimmed := bytes.TrimSpace(line)
+		if bytes.HasPrefix(trimmed, []byte("```")) || bytes.HasPrefix(trimmed, []byte("~~~")) {
+			inFence = !inFence
+		}
+		if inFence {
+			out.Write(line)
+			continue
+		}
+		out.Write(includeDirective.ReplaceAllFunc(line, func(directive []byte) []byte {
+			name := string(includeDirective.FindSubmatch(directive)[1])
+			included, err := s.readInclude(name, stack)
+			if err != nil {
+				return []byte(`<span class="include-error">` + template.HTMLEscapeString(err.Error()) + `</span>`)
+			}
+			return bytes.TrimRight(included, "\n")
+		}))
+	}
+	return out.Bytes()
+}
+
+// readInclude reads and expands an included file, rejecting unsafe paths,
+// cycles and includes nested too deeply
+func (s *Server) readInclude(name string, stack []string) ([]byte, error) {
+	if err := s.validatePath(name); err != nil {
+		return nil, fmt.Errorf("include %q: invalid path", name)
+	}
+	path := filepath.Join(s.config.ContentDir, name)
+	if !s.isPathSafe(path) {
+		return nil, fmt.Errorf("include %q: invalid path", name)
+	}
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("include %q: invalid path", name)
+	}
+
+	if len(stack) > maxIncludeDepth {
+		return nil, fmt.Errorf("include %q: too deeply nested (limit %d)", name, maxIncludeDepth)
+	}
+	for _, including := range stack {
+		if including == absPath {
+			return nil, fmt.Errorf("include %q: include cycle", name)
+		}
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("include %q: file not found", name)
+	}
+	_, body, err := parseFrontmatter(content)
+	if err != nil {
+		return nil, fmt.Errorf("include %q: %v", name, err)
+	}
+	return s.expandIncludesFrom(body, append(stack[:len(stack):len(stack)], absPath)), nil
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// parseCIDRs parses a list of CIDR ranges such as 10.0.0.0/8 or fd00::/8. A
+// bare address is a range of one.
+func parseCIDRs(key string, values []string) ([]*net.IPNet, error) {
+	var nets []*net.IPNet
+	for _, value := range values {
+		if !strings.Contains(value, "/") {
+			ip := net.ParseIP(value)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid %s entry %q: expected an IP address or CIDR range", key, value)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, ipNet, err := net.ParseCIDR(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s entry %q: expected an IP address or CIDR range", key, value)
+		}
+		nets = append(nets, ipNet)
+	}
+	return nets, nil
+}
+
+// containsIP reports whether any of nets contains ip
+func containsIP(nets []*net.IPNet, ip net.IP) bool {
+	for _, ipNet := range nets {
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// ipFilterMiddleware answers clients in IP_DENY, or outside IP_ALLOW when it
+// is set, with 403 Forbidden. The client IP honors TRUST_PROXY. The health
+// probes are always allowed, so orchestrators can reach them. It does nothing
+// when both lists are empty.
+func (s *Server) ipFilterMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	if len(s.config.IPAllow) == 0 && len(s.config.IPDeny) == 0 {
+		return next
+	}
+	// Both lists were checked by loadConfig
+	allow, _ := parseCIDRs("IP_ALLOW", s.config.IPAllow)
+	deny, _ := parseCIDRs("IP_DENY", s.config.IPDeny)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == healthzPath || r.URL.Path == readyzPath {
+			next(w, r)
+			return
+		}
+		ip := net.ParseIP(s.clientIP(r))
+		if ip == nil || containsIP(deny, ip) || len(allow) > 0 && !containsIP(allow, ip) {
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+		next(w, r)
+	}
+}
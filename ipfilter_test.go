@@ -0,0 +1,72 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestIPFilterMiddleware(t *testing.T) {
+	tests := []struct {
+		name       string
+		allow      []string
+		deny       []string
+		trustProxy bool
+		remoteAddr string
+		forwarded  string
+		path       string
+		status     int
+	}{
+		{"no lists", nil, nil, false, "203.0.113.9:1234", "", "/", http.StatusOK},
+		{"allowed", []string{"10.0.0.0/8"}, nil, false, "10.1.2.3:1234", "", "/", http.StatusOK},
+		{"not allowed", []string{"10.0.0.0/8"}, nil, false, "203.0.113.9:1234", "", "/", http.StatusForbidden},
+		{"denied", nil, []string{"203.0.113.0/24"}, false, "203.0.113.9:1234", "", "/", http.StatusForbidden},
+		{"not denied", nil, []string{"203.0.113.0/24"}, false, "198.51.100.1:1234", "", "/", http.StatusOK},
+		{"deny wins over allow", []string{"10.0.0.0/8"}, []string{"10.6.6.6"}, false, "10.6.6.6:1234", "", "/", http.StatusForbidden},
+		{"single address", []string{"192.0.2.7"}, nil, false, "192.0.2.7:1234", "", "/", http.StatusOK},
+		{"IPv6 allowed", []string{"fd00::/8"}, nil, false, "[fd12::1]:1234", "", "/", http.StatusOK},
+		{"IPv6 not allowed", []string{"fd00::/8"}, nil, false, "[2001:db8::1]:1234", "", "/", http.StatusForbidden},
+		{"IPv4 range against IPv6 client", []string{"10.0.0.0/8"}, nil, false, "[2001:db8::1]:1234", "", "/", http.StatusForbidden},
+		{"trusted proxy", []string{"10.0.0.0/8"}, nil, true, "203.0.113.1:1234", "10.1.2.3", "/", http.StatusOK},
+		{"untrusted forwarded header", []string{"10.0.0.0/8"}, nil, false, "203.0.113.1:1234", "10.1.2.3", "/", http.StatusForbidden},
+		{"probe always allowed", []string{"10.0.0.0/8"}, nil, false, "203.0.113.9:1234", "", healthzPath, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServer(t, map[string]string{"index.md": "# Home\n"}, func(c *Config) {
+				c.IPAllow, c.IPDeny = tt.allow, tt.deny
+				c.TrustProxy = tt.trustProxy
+			})
+			r := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			r.RemoteAddr = tt.remoteAddr
+			if tt.forwarded != "" {
+				r.Header.Set("X-Forwarded-For", tt.forwarded)
+			}
+			if w := serve(s, r); w.Code != tt.status {
+				t.Errorf("status = %d, want %d", w.Code, tt.status)
+			}
+		})
+	}
+}
+
+func TestIPFilterConfig(t *testing.T) {
+	tests := []struct {
+		name  string
+		allow []string
+		deny  []string
+		ok    bool
+	}{
+		{"ranges", []string{"10.0.0.0/8", "fd00::/8"}, []string{"10.6.6.6"}, true},
+		{"malformed CIDR", []string{"10.0.0.0/33"}, nil, false},
+		{"not an address", nil, []string{"intranet"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := defaultConfig()
+			config.IPAllow, config.IPDeny = tt.allow, tt.deny
+			if err := config.validate(); (err == nil) != tt.ok {
+				t.Errorf("validate = %v, want ok %v", err, tt.ok)
+			}
+		})
+	}
+}
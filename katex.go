@@ -0,0 +1,35 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gomarkdown/markdown/ast"
+)
+
+// katexScript is served from /__katex.js and renders the math spans produced
+// by the parser's MathJax extension, which wraps inline math in \(...\) and
+// display math in \[...\]. Dollar signs the parser left as text stay literal.
+const katexScript = `renderMathInElement(document.body, {
+    delimiters: [
+        { left: "\\[", right: "\\]", display: true },
+        { left: "\\(", right: "\\)", display: false }
+    ],
+    throwOnError: false
+});
+`
+
+// isMathNode reports whether node is inline or display math
+func isMathNode(node ast.Node) bool {
+	switch node.(type) {
+	case *ast.Math, *ast.MathBlock:
+		return true
+	}
+	return false
+}
+
+func (s *Server) handleKaTeXScript(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/javascript")
+	w.Header().Set("Cache-Control", s.config.CacheControl)
+	fmt.Fprint(w, katexScript)
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"net/http"
+	"time"
+)
+
+// Defaults, in seconds, for the timeouts of every HTTP server (READ_TIMEOUT,
+// WRITE_TIMEOUT and IDLE_TIMEOUT) and, in bytes, for MAX_BODY_SIZE
+const (
+	defaultReadTimeout  = 15
+	defaultWriteTimeout = 30
+	defaultIdleTimeout  = 120
+	defaultMaxBodySize  = 1 << 20
+)
+
+// newHTTPServer returns a server for handler on addr with the configured
+// timeouts, so a slow client can't hold a connection open indefinitely. A
+// timeout of zero disables it.
+func (s *Server) newHTTPServer(addr string, handler http.Handler) *http.Server {
+	return &http.Server{
+		Addr:         addr,
+		Handler:      handler,
+		ReadTimeout:  seconds(s.config.ReadTimeout),
+		WriteTimeout: seconds(s.config.WriteTimeout),
+		IdleTimeout:  seconds(s.config.IdleTimeout),
+	}
+}
+
+// limitBodyMiddleware caps request bodies at MAX_BODY_SIZE. Handlers only
+// accept GET and HEAD and never read a body, but net/http drains unread bodies
+// to reuse the connection, which this keeps short.
+func (s *Server) limitBodyMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		r.Body = http.MaxBytesReader(w, r.Body, int64(s.config.MaxBodySize))
+		next(w, r)
+	}
+}
+
+// seconds converts a timeout in seconds from the configuration to a Duration
+func seconds(n int) time.Duration {
+	return time.Duration(n) * time.Second
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"net/url"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/gomarkdown/markdown/ast"
+)
+
+// rewriteMarkdownLinks changes links to local markdown files into the clean
+// URLs they are served at: "setup.md#install" becomes "setup#install" and
+// "guide/index.md" (or the DEFAULT_DOCUMENT) becomes "guide/". External
+// links, anchors and links to other files are left untouched.
+//
+// With CANONICAL_URLS=slash a page such as guide/setup.md is served at
+// /guide/setup/, one level below the directory its relative links and images
+// were written for, so they get a "../" prefix. The default document is served
+// at its directory's URL and needs none.
+func (s *Server) rewriteMarkdownLinks(doc ast.Node, filePath string) {
+	nested := s.config.CanonicalURLs == canonicalSlash && filepath.Base(filePath) != s.indexFile
+	ast.WalkFunc(doc, func(node ast.Node, entering bool) ast.WalkStatus {
+		if !entering {
+			return ast.GoToNext
+		}
+		switch node := node.(type) {
+		case *ast.Link:
+			dest := s.cleanLinkDestination(string(node.Destination))
+			if nested {
+				dest = parentRelative(dest)
+			}
+			node.Destination = []byte(dest)
+		case *ast.Image:
+			if nested {
+				node.Destination = []byte(parentRelative(string(node.Destination)))
+			}
+		}
+		return ast.GoToNext
+	})
+}
+
+// parentRelative prefixes a relative URL with "../" so it resolves from one
+// directory deeper. Absolute URLs, root-relative paths, anchors and queries
+// are returned unchanged.
+func parentRelative(dest string) string {
+	if dest == "" || strings.HasPrefix(dest, "/") || strings.HasPrefix(dest, "#") || strings.HasPrefix(dest, "?") {
+		return dest
+	}
+	if u, err := url.Parse(dest); err != nil || u.Scheme != "" || u.Host != "" {
+		return dest
+	}
+	return "../" + strings.TrimPrefix(dest, "./")
+}
+
+// cleanLinkDestination returns the clean URL for a link to a local markdown
+// file, or dest unchanged for any other link. Links to the default document
+// point to its directory.
+func (s *Server) cleanLinkDestination(dest string) string {
+	if u, err := url.Parse(dest); err != nil || u.Scheme != "" || u.Host != "" {
+		return dest
+	}
+
+	// Split off the query and fragment so they can be preserved as written
+	target, suffix := dest, ""
+	if i := strings.IndexAny(dest, "?#"); i >= 0 {
+		target, suffix = dest[:i], dest[i:]
+	}
+	if !strings.HasSuffix(target, ".md") {
+		return dest
+	}
+
+	target = strings.TrimSuffix(target, ".md")
+	if indexName := strings.TrimSuffix(s.indexFile, ".md"); path.Base(target) == indexName {
+		target = strings.TrimSuffix(target, indexName)
+		if target == "" {
+			target = "./"
+		}
+	}
+	return target + suffix
+}
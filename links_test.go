@@ -0,0 +1,86 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/gomarkdown/markdown/ast"
+)
+
+func TestCleanLinkDestination(t *testing.T) {
+	tests := []struct {
+		name      string
+		indexFile string
+		dest      string
+		want      string
+	}{
+		{"relative", "", "other.md", "other"},
+		{"nested relative", "", "../guide/setup.md", "../guide/setup"},
+		{"fragment", "", "other.md#section", "other#section"},
+		{"query", "", "other.md?v=1#top", "other?v=1#top"},
+		{"root relative", "", "/docs/setup.md", "/docs/setup"},
+		{"index", "", "guide/index.md", "guide/"},
+		{"index in same directory", "", "index.md#intro", "./#intro"},
+		{"page named like index", "", "reindex.md", "reindex"},
+		{"default document", "README.md", "guide/README.md", "guide/"},
+		{"index with default document", "README.md", "guide/index.md", "guide/index"},
+		{"anchor only", "", "#section", "#section"},
+		{"other file", "", "image.png", "image.png"},
+		{"external", "", "https://example.com/readme.md", "https://example.com/readme.md"},
+		{"protocol relative", "", "//example.com/readme.md", "//example.com/readme.md"},
+		{"mailto", "", "mailto:someone@example.com", "mailto:someone@example.com"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServer(t, nil, func(c *Config) {
+				if tt.indexFile != "" {
+					c.DefaultDocument = tt.indexFile
+				}
+			})
+			if got := s.cleanLinkDestination(tt.dest); got != tt.want {
+				t.Errorf("cleanLinkDestination(%q) = %q, want %q", tt.dest, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRewriteMarkdownLinks(t *testing.T) {
+	tests := []struct {
+		name      string
+		canonical string
+		filePath  string
+		link      string
+		image     string
+		wantLink  string
+		wantImage string
+	}{
+		{"default", "", "guide/setup.md", "other.md#x", "img/a.png", "other#x", "img/a.png"},
+		{"slash page", canonicalSlash, "guide/setup.md", "other.md#x", "img/a.png", "../other#x", "../img/a.png"},
+		{"slash index", canonicalSlash, "guide/index.md", "other.md", "img/a.png", "other", "img/a.png"},
+		{"slash dot relative", canonicalSlash, "setup.md", "./other.md", "./a.png", "../other", "../a.png"},
+		{"slash root relative", canonicalSlash, "setup.md", "/docs/other.md", "/a.png", "/docs/other", "/a.png"},
+		{"slash anchor", canonicalSlash, "setup.md", "#install", "https://example.com/a.png", "#install", "https://example.com/a.png"},
+		{"slash external", canonicalSlash, "setup.md", "https://example.com/", "data:image/png;base64,AA==", "https://example.com/", "data:image/png;base64,AA=="},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServer(t, nil, func(c *Config) {
+				if tt.canonical != "" {
+					c.CanonicalURLs = tt.canonical
+				}
+			})
+			l := link(tt.link, "see")
+			img := &ast.Image{Destination: []byte(tt.image)}
+			paragraph := &ast.Paragraph{}
+			ast.AppendChild(paragraph, l)
+			ast.AppendChild(paragraph, img)
+
+			s.rewriteMarkdownLinks(document(paragraph), tt.filePath)
+			if got := string(l.Destination); got != tt.wantLink {
+				t.Errorf("link = %q, want %q", got, tt.wantLink)
+			}
+			if got := string(img.Destination); got != tt.wantImage {
+				t.Errorf("image = %q, want %q", got, tt.wantImage)
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"bytes"
+	"html/template"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// listingEntry is a link shown in a directory listing
+type listingEntry struct {
+	Title string
+	URL   string
+	IsDir bool
+}
+
+// listingTemplate renders the body of a directory listing page
+var listingTemplate = template.Must(template.New("listing").Parse(`<h1>{{.Title}}</h1>
+<ul class="directory-listing">
+{{- range .Entries}}
+    <li{{if .IsDir}} class="directory"{{end}}><a href="{{.URL}}">{{.Title}}</a></li>
+{{- else}}
+    <li>This folder is empty.</li>
+{{- end}}
+</ul>`))
+
+// handleDirectoryListing renders links to the markdown files and subdirectories
+// of a directory that has no default document, directories first and each sorted by
+// title. Hidden entries and style.css are skipped, and file links use each
+// page's title.
+func (s *Server) handleDirectoryListing(w http.ResponseWriter, r *http.Request, dirPath, urlPath string) {
+	if !s.isPathSafe(dirPath) {
+		http.Error(w, "Invalid path", http.StatusBadRequest)
+		return
+	}
+	if !s.isPublic(nil, urlPath) {
+		s.handleNotFound(w, r)
+		return
+	}
+
+	entries, err := s.readContentDir(urlPath)
+	if err != nil {
+		http.Error(w, "Error reading directory", http.StatusInternalServerError)
+		return
+	}
+
+	var links []listingEntry
+	for _, entry := range entries {
+		name := entry.Name()
+		if strings.HasPrefix(name, ".") || name == styleSheetName {
+			continue
+		}
+
+		if entry.IsDir() {
+			if !s.isPublic(nil, urlPath+name) {
+				continue
+			}
+			links = append(links, listingEntry{
+				Title: name + "/",
+				URL:   s.urlPrefix + "/" + urlPath + name + "/",
+				IsDir: true,
+			})
+			continue
+		}
+
+		if filepath.Ext(name) != ".md" || !s.isPublicFile(urlPath+name) {
+			continue
+		}
+		links = append(links, listingEntry{
+			Title: s.fileTitle(filepath.Join(dirPath, name)),
+			URL:   s.urlPrefix + "/" + urlPath + strings.TrimSuffix(name, ".md"),
+		})
+	}
+
+	// Directories first, then pages, each in alphabetical order
+	sort.SliceStable(links, func(i, j int) bool {
+		if links[i].IsDir != links[j].IsDir {
+			return links[i].IsDir
+		}
+		return strings.ToLower(links[i].Title) < strings.ToLower(links[j].Title)
+	})
+
+	title := "Index of " + s.urlPrefix + "/" + urlPath
+	var body bytes.Buffer
+	if err := listingTemplate.Execute(&body, struct {
+		Title   string
+		Entries []listingEntry
+	}{title, links}); err != nil {
+		http.Error(w, "Template execution error", http.StatusInternalServerError)
+		return
+	}
+
+	s.renderPage(w, r, http.StatusOK, pageData{
+		Title:      title,
+		Content:    template.HTML(body.String()),
+		StyleSheet: s.styleSheetURL(urlPath),
+	})
+}
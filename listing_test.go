@@ -0,0 +1,31 @@
+package main
+
+import "testing"
+
+func TestDirectoryListingEnv(t *testing.T) {
+	tests := []struct {
+		name              string
+		directoryListing  string
+		disableDirListing string
+		want              bool
+	}{
+		{"default", "", "", false},
+		{"enabled", "true", "", true},
+		{"deprecated setting enables", "", "false", true},
+		{"deprecated setting disables", "", "true", false},
+		{"new setting wins", "true", "true", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("DIRECTORY_LISTING", tt.directoryListing)
+			t.Setenv("DISABLE_DIR_LISTING", tt.disableDirListing)
+			config, err := loadConfig(nil)
+			if err != nil {
+				t.Fatalf("loadConfig: %v", err)
+			}
+			if config.DirectoryListing != tt.want {
+				t.Errorf("DirectoryListing = %v, want %v", config.DirectoryListing, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// liveReloadScript is served from /__livereload.js rather than inlined so it
+// works under the default script-src 'self' Content Security Policy
+const liveReloadScript = `(function () {
+    var source = new EventSource("/__livereload");
+    source.addEventListener("reload", function () {
+        location.reload();
+    });
+})();
+`
+
+// liveReloader fans content change notifications out to connected SSE clients
+type liveReloader struct {
+	mu      sync.Mutex
+	clients map[chan struct{}]struct{}
+
+	// done is closed on shutdown to end the streams, which never finish on
+	// their own and would otherwise hold up a graceful shutdown
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func newLiveReloader() *liveReloader {
+	return &liveReloader{
+		clients: make(map[chan struct{}]struct{}),
+		done:    make(chan struct{}),
+	}
+}
+
+// close ends every live reload stream
+func (lr *liveReloader) close() {
+	lr.closeOnce.Do(func() { close(lr.done) })
+}
+
+func (lr *liveReloader) subscribe() chan struct{} {
+	// Buffer a single pending reload so bursts of file events collapse into one
+	ch := make(chan struct{}, 1)
+	lr.mu.Lock()
+	lr.clients[ch] = struct{}{}
+	lr.mu.Unlock()
+	return ch
+}
+
+func (lr *liveReloader) unsubscribe(ch chan struct{}) {
+	lr.mu.Lock()
+	delete(lr.clients, ch)
+	lr.mu.Unlock()
+}
+
+func (lr *liveReloader) broadcast() {
+	lr.mu.Lock()
+	defer lr.mu.Unlock()
+	for ch := range lr.clients {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// handleLiveReload streams a "reload" Server-Sent Event whenever content changes
+func (s *Server) handleLiveReload(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	// The stream stays open until the page is closed, well past WRITE_TIMEOUT
+	http.NewResponseController(w).SetWriteDeadline(time.Time{})
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	ch := s.liveReload.subscribe()
+	defer s.liveReload.unsubscribe(ch)
+
+	for {
+		select {
+		case <-r.Context().Done():
+			// Client disconnected
+			return
+		case <-s.liveReload.done:
+			// Server shutting down; the browser reconnects to the next one
+			return
+		case <-ch:
+			fmt.Fprint(w, "event: reload\ndata: {}\n\n")
+			flusher.Flush()
+		}
+	}
+}
+
+func (s *Server) handleLiveReloadScript(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/javascript")
+	w.Header().Set("Cache-Control", "no-cache")
+	fmt.Fprint(w, liveReloadScript)
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Access log formats (LOG_FORMAT)
+const (
+	logFormatText     = "text"
+	logFormatJSON     = "json"
+	logFormatCombined = "combined" // Apache/nginx combined log format
+)
+
+// responseWriter records the status code and number of bytes written so they
+// can be logged after the handler returns
+type responseWriter struct {
+	http.ResponseWriter
+	status int
+	size   int
+}
+
+func (rw *responseWriter) WriteHeader(status int) {
+	if rw.status == 0 {
+		rw.status = status
+	}
+	rw.ResponseWriter.WriteHeader(status)
+}
+
+func (rw *responseWriter) Write(b []byte) (int, error) {
+	if rw.status == 0 {
+		rw.status = http.StatusOK
+	}
+	n, err := rw.ResponseWriter.Write(b)
+	rw.size += n
+	return n, err
+}
+
+// Flush lets streaming handlers such as live reload work through the wrapper
+func (rw *responseWriter) Flush() {
+	if flusher, ok := rw.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
+// newLogger creates the logger for startup, shutdown and error messages, in
+// the LOG_FORMAT of the access log and at LOG_LEVEL. The combined format only
+// applies to access logs; other messages are written as text.
+func newLogger(format string, level slog.Level) *slog.Logger {
+	opts := &slog.HandlerOptions{Level: level}
+	if format == logFormatJSON {
+		return slog.New(slog.NewJSONHandler(os.Stderr, opts))
+	}
+	return slog.New(slog.NewTextHandler(os.Stderr, opts))
+}
+
+// fatal logs an error that prevents the server from running and exits
+func fatal(msg string, err error) {
+	slog.Error(msg, "err", err)
+	os.Exit(1)
+}
+
+// newAccessLogger creates the logger used for access logs in the given format
+func newAccessLogger(format string) *slog.Logger {
+	if format == logFormatJSON {
+		return slog.New(slog.NewJSONHandler(os.Stdout, nil))
+	}
+	return slog.New(slog.NewTextHandler(os.Stdout, nil))
+}
+
+// loggingMiddleware logs the method, path, status code, response size and
+// duration of every request
+func (s *Server) loggingMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rw := &responseWriter{ResponseWriter: w}
+
+		next(rw, r)
+
+		status := rw.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		if s.config.LogFormat == logFormatCombined {
+			writeCombinedLog(os.Stdout, r, s.clientIP(r), status, rw.size, start)
+			return
+		}
+		s.accessLog.Info("request",
+			"method", r.Method,
+			"path", r.URL.Path,
+			"status", status,
+			"size", rw.size,
+			"duration", time.Since(start),
+		)
+	}
+}
+
+// writeCombinedLog writes a request as a line in the combined log format
+// understood by most log analyzers:
+//
+//	127.0.0.1 - alice [15/Jan/2024:10:00:00 +0000] "GET /docs HTTP/1.1" 200 1234 "-" "curl/8.0"
+func writeCombinedLog(out io.Writer, r *http.Request, host string, status, size int, start time.Time) {
+	user, _, _ := r.BasicAuth()
+	bytes := "-"
+	if size > 0 {
+		bytes = strconv.Itoa(size)
+	}
+	fmt.Fprintf(out, "%s - %s [%s] %q %d %s %q %q\n",
+		host,
+		orDash(user),
+		start.Format("02/Jan/2006:15:04:05 -0700"),
+		r.Method+" "+r.URL.RequestURI()+" "+r.Proto,
+		status,
+		bytes,
+		orDash(r.Referer()),
+		orDash(r.UserAgent()),
+	)
+}
+
+// orDash returns s, or "-" for an empty field in the combined log format
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
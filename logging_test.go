@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestLoggingMiddleware(t *testing.T) {
+	// No index.md, which missing pages would fall back to
+	s := newTestServer(t, map[string]string{"page.md": "# Page\n"}, nil)
+	var buf bytes.Buffer
+	s.accessLog = slog.New(slog.NewJSONHandler(&buf, nil))
+
+	tests := []struct {
+		method string
+		target string
+		path   string
+		status int
+	}{
+		{http.MethodGet, "/page?q=1", "/page", http.StatusOK},
+		{http.MethodGet, "/missing", "/missing", http.StatusNotFound},
+		{http.MethodPost, "/page", "/page", http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.target, func(t *testing.T) {
+			buf.Reset()
+			w := serve(s, httptest.NewRequest(tt.method, tt.target, nil))
+
+			var entry struct {
+				Msg      string `json:"msg"`
+				Method   string `json:"method"`
+				Path     string `json:"path"`
+				Status   int    `json:"status"`
+				Size     int    `json:"size"`
+				Duration int64  `json:"duration"`
+			}
+			if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+				t.Fatalf("access log %q is not one JSON line: %v", buf.String(), err)
+			}
+			if entry.Msg != "request" || entry.Method != tt.method || entry.Path != tt.path || entry.Status != tt.status {
+				t.Errorf("logged %+v, want %s %s %d", entry, tt.method, tt.path, tt.status)
+			}
+			if entry.Size != w.Body.Len() {
+				t.Errorf("size = %d, want %d", entry.Size, w.Body.Len())
+			}
+		})
+	}
+}
+
+func TestResponseWriterStatus(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler func(w http.ResponseWriter)
+		status  int
+		size    int
+	}{
+		{"write only", func(w http.ResponseWriter) { w.Write([]byte("hello")) }, http.StatusOK, 5},
+		{"explicit status", func(w http.ResponseWriter) { w.WriteHeader(http.StatusTeapot); w.Write([]byte("hi")) }, http.StatusTeapot, 2},
+		{"first status wins", func(w http.ResponseWriter) { w.WriteHeader(http.StatusNotFound); w.WriteHeader(http.StatusOK) }, http.StatusNotFound, 0},
+		{"nothing written", func(w http.ResponseWriter) {}, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rw := &responseWriter{ResponseWriter: httptest.NewRecorder()}
+			tt.handler(rw)
+			if rw.status != tt.status || rw.size != tt.size {
+				t.Errorf("recorded %d, %d bytes, want %d, %d bytes", rw.status, rw.size, tt.status, tt.size)
+			}
+		})
+	}
+}
+
+func TestWriteCombinedLog(t *testing.T) {
+	start := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	withAuth := httptest.NewRequest(http.MethodGet, "/docs?page=2", nil)
+	withAuth.SetBasicAuth("alice", "secret")
+	withAuth.Header.Set("Referer", "https://example.com/")
+	withAuth.Header.Set("User-Agent", "curl/8.0")
+	anonymous := httptest.NewRequest(http.MethodHead, "/", nil)
+	anonymous.Header.Del("User-Agent")
+
+	tests := []struct {
+		name string
+		r    *http.Request
+		size int
+		want string
+	}{
+		{"all fields", withAuth, 1234, `127.0.0.1 - alice [15/Jan/2024:10:00:00 +0000] "GET /docs?page=2 HTTP/1.1" 200 1234 "https://example.com/" "curl/8.0"` + "\n"},
+		{"empty fields", anonymous, 0, `127.0.0.1 - - [15/Jan/2024:10:00:00 +0000] "HEAD / HTTP/1.1" 200 - "-" "-"` + "\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			writeCombinedLog(&buf, tt.r, "127.0.0.1", http.StatusOK, tt.size, start)
+			if buf.String() != tt.want {
+				t.Errorf("got  %s\nwant %s", buf.String(), tt.want)
+			}
+		})
+	}
+}
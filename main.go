@@ -1,202 +1,875 @@
 package main
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"html/template"
-	"log"
+	"io"
+	"log/slog"
 	"net/http"
 	"os"
+	"os/signal"
+	"path"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"sync"
+	"syscall"
+	"time"
 
-	"github.com/gomarkdown/markdown"
+	"github.com/gomarkdown/markdown/ast"
 	"github.com/gomarkdown/markdown/html"
 	"github.com/gomarkdown/markdown/parser"
 )
 
 type Server struct {
-	contentDir           string
-	port                string
-	enableSecurityHeaders bool
+	config       Config
+	rendererOpts html.RendererOptions
+	liveReload   *liveReloader
+
+	jsonFeedCache    feedCache
+	xmlFeedCache     feedCache
+	atomFeedCache    feedCache
+	searchIndexCache feedCache
+	refs             refIndex
+	search           searchIndex
+
+	// urlPrefix is prepended to generated links when serving a mount
+	urlPrefix string
+	// indexFile is served for directory requests, index.md unless a mount sets another
+	indexFile string
+	mounts    []mountedServer
+
+	accessLog *slog.Logger
+	templates *templateStore
+	metrics   *metrics
+	nav       []navItem
+	redirects []redirectRule
+	// pdfSlots limits concurrent PDF exports to PDF_CONCURRENCY
+	pdfSlots     chan struct{}
+	navTreeCache navTreeCache
+	// htpasswd holds the users of BASIC_AUTH_FILE, if set
+	htpasswd *htpasswdFile
+	// treeCache holds the scanned content tree, see TREE_CACHE_TTL
+	treeCache treeCache
+
+	// done is closed on shutdown to stop background work, such as the rate
+	// limiter's cleanup of idle clients
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// markdownExtensions are the parser extensions enabled for every document
+const markdownExtensions = parser.CommonExtensions | parser.AutoHeadingIDs
+
+func NewServer(config Config) (*Server, error) {
+	templates, err := newTemplateStore(config.TemplateFile)
+	if err != nil {
+		return nil, err
+	}
+	s := &Server{
+		config:    config,
+		templates: templates,
+		indexFile: config.DefaultDocument,
+		done:      make(chan struct{}),
+	}
+	s.rendererOpts = html.RendererOptions{
+		Flags:          html.CommonFlags | html.HrefTargetBlank,
+		RenderNodeHook: s.renderNodeHook,
+	}
+	if config.LiveReload {
+		s.liveReload = newLiveReloader()
+	}
+	if config.EnableMetrics {
+		s.metrics = newMetrics(s)
+		s.jsonFeedCache.observe = s.metrics.observeCache("json_feed")
+		s.xmlFeedCache.observe = s.metrics.observeCache("xml_feed")
+		s.atomFeedCache.observe = s.metrics.observeCache("atom_feed")
+		s.searchIndexCache.observe = s.metrics.observeCache("search_index")
+	}
+	s.pdfSlots = make(chan struct{}, config.PDFConcurrency)
+	if config.BasicAuthFile != "" {
+		if s.htpasswd, err = loadHtpasswd(config.BasicAuthFile); err != nil {
+			return nil, err
+		}
+	}
+	s.mounts, err = s.newMountedServers()
+	if err != nil {
+		return nil, err
+	}
+	s.accessLog = newAccessLogger(config.LogFormat)
+	return s, nil
 }
 
-func NewServer(contentDir, port string, enableSecurityHeaders bool) *Server {
-	return &Server{
-		contentDir:           contentDir,
-		port:                port,
-		enableSecurityHeaders: enableSecurityHeaders,
+// contentServers returns the servers that serve content: one per mount when
+// mounts are configured, otherwise just s
+func (s *Server) contentServers() []*Server {
+	if len(s.mounts) == 0 {
+		return []*Server{s}
+	}
+	servers := make([]*Server, len(s.mounts))
+	for i, m := range s.mounts {
+		servers[i] = m.server
 	}
+	return servers
 }
 
+// Start serves until the process receives SIGINT or SIGTERM, then shuts down
+// gracefully
 func (s *Server) Start() error {
-	http.HandleFunc("/", s.securityHeadersMiddleware(s.handleMarkdown))
-	
-	fmt.Printf("Starting server on port %s, serving content from %s\n", s.port, s.contentDir)
-	return http.ListenAndServe(":"+s.port, nil)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	return s.Run(ctx)
+}
+
+// Run serves until ctx is cancelled, then shuts down gracefully
+func (s *Server) Run(ctx context.Context) error {
+	// Build the global reference index used to resolve ref: links
+	if s.config.CrossRefs {
+		for _, server := range s.contentServers() {
+			if err := server.buildRefIndex(); err != nil {
+				return fmt.Errorf("failed to build reference index: %w", err)
+			}
+		}
+	}
+
+	// Load each content directory's navigation menu and redirects
+	for _, server := range s.contentServers() {
+		if err := server.loadNav(); err != nil {
+			return err
+		}
+		if err := server.loadRedirects(); err != nil {
+			return err
+		}
+	}
+
+	// Build the full-text search index without delaying startup
+	for _, server := range s.contentServers() {
+		server.rebuildSearchIndex()
+	}
+
+	// Live reload: watch the content directory and notify browsers over SSE
+	if s.liveReload != nil {
+		for _, server := range s.contentServers() {
+			if err := server.watchContent(); err != nil {
+				return fmt.Errorf("failed to watch content directory: %w", err)
+			}
+		}
+		slog.Info("live reload enabled")
+	}
+
+	// Reload the external page template on change in dev mode
+	if s.config.TemplateFile != "" && s.config.DevMode {
+		if err := s.templates.watch(); err != nil {
+			return fmt.Errorf("failed to watch template: %w", err)
+		}
+	}
+
+	handler := s.handler()
+
+	// Obtain certificates automatically when domains are configured, or serve
+	// HTTPS when a certificate is, optionally with HTTP/3 alongside
+	var listeners []listener
+	switch {
+	case len(s.config.AutocertDomains) > 0:
+		listeners = s.autocertListeners(handler)
+	case s.config.TLSCertFile != "":
+		listeners = s.tlsListeners(handler)
+	default:
+		server := s.newHTTPServer(s.config.listenAddr(), handler)
+		slog.Info("starting server", "addr", server.Addr, "content_dir", s.config.ContentDir)
+		listeners = []listener{httpListener(server, server.ListenAndServe)}
+	}
+	return s.runListeners(ctx, listeners)
+}
+
+// handler returns the router wrapped in the middleware applied to every route
+func (s *Server) handler() http.HandlerFunc {
+	return s.loggingMiddleware(s.metricsMiddleware(s.recoverMiddleware(s.ipFilterMiddleware(s.rateLimitMiddleware(s.limitBodyMiddleware(s.basicAuthMiddleware(s.newRouter().ServeHTTP)))))))
+}
+
+// defaultFrameAncestors allows the site to be embedded in iframes anywhere.
+// X-Frame-Options is only sent when FRAME_POLICY restricts framing.
+const defaultFrameAncestors = "*"
+
+// cdnOrigin serves the client-side libraries for diagrams and math. It is
+// added to the default policy only on pages that use them.
+const cdnOrigin = "https://cdn.jsdelivr.net"
+
+// contentSecurityPolicy returns the CSP_POLICY if one is configured, otherwise
+// the default policy with the frame-ancestors of FRAME_POLICY or
+// CSP_FRAME_ANCESTORS and CSP_DIRECTIVES.
+// The default policy also allows the external scripts needed by the features
+// a page uses. It returns "" when DISABLE_CSP is set.
+func (c Config) contentSecurityPolicy(features pageFeatures) string {
+	if c.DisableCSP {
+		return ""
+	}
+	if c.CSP != "" {
+		return c.CSP
+	}
+	scriptSrc, styleSrc, fontSrc := "'self'", "'self' 'unsafe-inline'", "'self'"
+	if features.Mermaid || features.Math {
+		scriptSrc += " " + cdnOrigin
+	}
+	if features.Math {
+		styleSrc += " " + cdnOrigin
+		fontSrc += " " + cdnOrigin
+	}
+	return withCSPAdditions([]cspDirective{
+		{"default-src", "'self'"},
+		{"style-src", styleSrc},
+		{"script-src", scriptSrc},
+		{"img-src", "'self' data: https:"},
+		{"font-src", fontSrc},
+		{"connect-src", "'self'"},
+		{"frame-ancestors", c.frameAncestors()},
+		{"base-uri", "'self'"},
+	}, c.CSPDirectives)
 }
 
 // securityHeadersMiddleware adds security headers to all responses if enabled
 func (s *Server) securityHeadersMiddleware(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// Only add security headers if enabled
-		if s.enableSecurityHeaders {
+		if s.config.EnableSecurityHeaders {
 			w.Header().Set("X-Content-Type-Options", "nosniff")
 			w.Header().Set("X-XSS-Protection", "1; mode=block")
 			w.Header().Set("Referrer-Policy", "strict-origin-when-cross-origin")
 			w.Header().Set("X-Permitted-Cross-Domain-Policies", "none")
-			
-			// Content Security Policy - allowing iframe embedding as requested
-			// Note: Omitting X-Frame-Options since user wants iframe support
-			csp := "default-src 'self'; " +
-				"style-src 'self' 'unsafe-inline'; " +
-				"script-src 'self'; " +
-				"img-src 'self' data: https:; " +
-				"font-src 'self'; " +
-				"connect-src 'self'; " +
-				"frame-ancestors *; " + // Allow iframe embedding
-				"base-uri 'self'"
-			w.Header().Set("Content-Security-Policy", csp)
-		}
-		
+			if frameOptions := s.config.frameOptions(); frameOptions != "" {
+				w.Header().Set("X-Frame-Options", frameOptions)
+			}
+
+			// Content Security Policy - the configured policy replaces the default
+			if policy := s.config.contentSecurityPolicy(pageFeatures{}); policy != "" {
+				w.Header().Set("Content-Security-Policy", policy)
+			}
+		}
+
 		// Call the next handler
 		next(w, r)
 	}
 }
 
 func (s *Server) handleMarkdown(w http.ResponseWriter, r *http.Request) {
+	// Moved pages redirect before anything is looked up on disk
+	if s.redirect(w, r) {
+		return
+	}
+
 	// Clean the URL path
 	urlPath := strings.TrimPrefix(r.URL.Path, "/")
 	if urlPath == "" {
-		urlPath = "index.md"
+		urlPath = s.indexFile
 	}
-	
+
 	// Security: Validate and sanitize the path to prevent directory traversal
 	if err := s.validatePath(urlPath); err != nil {
 		http.Error(w, "Invalid path", http.StatusBadRequest)
 		return
 	}
-	
-	// Handle CSS file requests
-	if urlPath == "style.css" {
-		cssPath := filepath.Join(s.contentDir, "style.css")
+
+	// Stylesheets fall back to the nearest parent directory's
+	if filepath.Base(urlPath) == styleSheetName {
+		s.serveStyleSheet(w, r, urlPath)
+		return
+	}
+
+	// Handle other static assets (images, PDFs, scripts) stored alongside the markdown
+	if ext := filepath.Ext(urlPath); ext != "" && ext != ".md" && !isHiddenPath(urlPath) {
+		assetPath := filepath.Join(s.config.ContentDir, urlPath)
 		// Security: Ensure the resolved path is still within content directory
-		if !s.isPathSafe(cssPath) {
+		if !s.isPathSafe(assetPath) {
 			http.Error(w, "Invalid path", http.StatusBadRequest)
 			return
 		}
-		if _, err := os.Stat(cssPath); err == nil {
-			w.Header().Set("Content-Type", "text/css")
-			http.ServeFile(w, r, cssPath)
+		if info, err := os.Stat(assetPath); err == nil && !info.IsDir() {
+			if contentType := assetContentType(ext); contentType != "" {
+				w.Header().Set("Content-Type", contentType)
+			}
+			w.Header().Set("Cache-Control", s.config.assetCacheControl())
+			if servePrecompressed(w, r, s.openContent, urlPath) {
+				return
+			}
+			http.ServeFile(w, r, assetPath)
+			return
+		}
+		// A missing image or script is a plain 404, not the index.md fallback
+		if isStaticAsset(ext) {
+			http.NotFound(w, r)
 			return
 		}
-		http.NotFound(w, r)
+	}
+
+	// Redirect to the canonical URL before anything is rendered
+	if s.canonicalize(w, r, strings.TrimPrefix(r.URL.Path, "/")) {
 		return
 	}
-	
+
 	// Add .md extension if not present and not a directory
 	if !strings.HasSuffix(urlPath, ".md") && !strings.HasSuffix(urlPath, "/") {
 		urlPath += ".md"
 	}
-	
-	filePath := filepath.Join(s.contentDir, urlPath)
-	
+
+	filePath := filepath.Join(s.config.ContentDir, urlPath)
+
 	// Security: Ensure the resolved path is still within content directory
 	if !s.isPathSafe(filePath) {
 		http.Error(w, "Invalid path", http.StatusBadRequest)
 		return
 	}
-	
-	// Check if file exists
-	if _, err := os.Stat(filePath); os.IsNotExist(err) {
-		// Try with index.md if it's a directory
-		if strings.HasSuffix(urlPath, "/") {
-			indexPath := filepath.Join(s.contentDir, urlPath, "index.md")
-			if !s.isPathSafe(indexPath) {
-				http.Error(w, "Invalid path", http.StatusBadRequest)
-				return
-			}
+
+	// Directory requests serve the directory's default document, or a listing when it has none
+	if strings.HasSuffix(urlPath, "/") {
+		indexPath := filepath.Join(s.config.ContentDir, urlPath, s.indexFile)
+		if !s.isPathSafe(indexPath) {
+			http.Error(w, "Invalid path", http.StatusBadRequest)
+			return
+		}
+		if _, err := os.Stat(indexPath); err == nil {
 			filePath = indexPath
+		} else if info, err := os.Stat(filePath); err == nil && info.IsDir() && s.config.DirectoryListing {
+			s.handleDirectoryListing(w, r, filePath, urlPath)
+			return
+		} else if pagePath := strings.TrimSuffix(urlPath, "/") + ".md"; pagePath != ".md" && s.isContentFile(pagePath) {
+			// /page/ is page.md when there is no such directory, as with CANONICAL_URLS=slash
+			filePath = filepath.Join(s.config.ContentDir, pagePath)
 		} else {
-			// If the requested file doesn't exist, try to serve index.md instead
-			indexPath := filepath.Join(s.contentDir, "index.md")
-			if !s.isPathSafe(indexPath) {
-				http.Error(w, "Invalid path", http.StatusBadRequest)
-				return
-			}
-			if _, indexErr := os.Stat(indexPath); indexErr == nil {
-				filePath = indexPath
-			} else {
-				http.NotFound(w, r)
-				return
-			}
+			s.handleNotFound(w, r)
+			return
+		}
+	} else if _, err := os.Stat(filePath); os.IsNotExist(err) {
+		// A custom 404.md takes precedence over the default document fallback
+		if s.renderNotFoundPage(w, r) {
+			return
 		}
+
+		// If the requested file doesn't exist, try to serve the default document instead
+		indexPath := filepath.Join(s.config.ContentDir, s.indexFile)
+		if !s.isPathSafe(indexPath) {
+			http.Error(w, "Invalid path", http.StatusBadRequest)
+			return
+		}
+		if _, indexErr := os.Stat(indexPath); indexErr == nil {
+			filePath = indexPath
+		} else {
+			http.NotFound(w, r)
+			return
+		}
+	}
+
+	s.serveMarkdownFile(w, r, filePath, http.StatusOK)
+}
+
+// serveMarkdownFile renders a markdown file into the page template, or as JSON
+// when the client asks for it. The page is served with status unless its
+// frontmatter overrides it.
+func (s *Server) serveMarkdownFile(w http.ResponseWriter, r *http.Request, filePath string, status int) {
+	// Read markdown file. If 404.md disappears after it was found, answer with
+	// the plain-text 404 instead of an error about the error page.
+	info, err := os.Stat(filePath)
+	var content []byte
+	if err == nil {
+		content, err = os.ReadFile(filePath)
 	}
-	
-	// Read markdown file
-	content, err := os.ReadFile(filePath)
 	if err != nil {
+		if status == http.StatusNotFound {
+			http.NotFound(w, r)
+			return
+		}
 		http.Error(w, "Error reading file", http.StatusInternalServerError)
 		return
 	}
-	
+
+	// Split off any frontmatter so it isn't rendered as markdown
+	source := content
+	meta, content, err := parseFrontmatter(content)
+	if err != nil {
+		slog.Warn("invalid frontmatter", "path", filePath, "err", err)
+	}
+
+	// Drafts are only served in preview mode. A draft 404.md or 500.md falls
+	// back to the plain-text response rather than looking itself up again.
+	draft := isDraft(meta, s.contentRelPath(filePath))
+	if !s.isPublic(meta, s.contentRelPath(filePath)) {
+		switch status {
+		case http.StatusOK:
+			s.handleNotFound(w, r)
+		case http.StatusInternalServerError:
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		default:
+			http.NotFound(w, r)
+		}
+		return
+	}
+
+	// Tools asking for the markdown get the file itself, skipping rendering
+	if wantsMarkdown(r) {
+		w.Header().Add("Vary", "Accept")
+		if r.URL.Query().Get("frontmatter") == "0" {
+			source = content
+		}
+		s.writeText(w, r, status, "text/markdown; charset=utf-8", source, info.ModTime())
+		return
+	}
+
+	// Plain text has the markdown syntax, code blocks included, taken out
+	if wantsText(r) {
+		text := markdownText(s.expandIncludes(content, filePath), true) + "\n"
+		s.writeText(w, r, pageStatus(meta, filePath, status), "text/plain; charset=utf-8", []byte(text), info.ModTime())
+		return
+	}
+
 	// Convert markdown to HTML
-	htmlContent := s.markdownToHTML(content)
-	
-	// Render with template
-	tmpl := `<!DOCTYPE html>
+	title := s.titleForFile(meta, content, filePath)
+	rendered := s.markdownToHTML(content, title, filePath)
+
+	// The same URL serves HTML or JSON depending on the request
+	w.Header().Add("Vary", "Accept")
+	status = pageStatus(meta, filePath, status)
+	if wantsJSON(r) {
+		writePageJSON(w, status, pageJSON{
+			Title:     title,
+			HTML:      rendered.HTML,
+			Meta:      meta,
+			WordCount: wordCount(content),
+		})
+		return
+	}
+
+	// Link to the neighbouring pages in reading order, style the page with its
+	// section's stylesheet and give it an absolute URL for link previews
+	var prev, next *pageLink
+	styleSheet, pageURL, editURL := "", "", ""
+	if relPath, err := filepath.Rel(s.config.ContentDir, filePath); err == nil {
+		relPath = filepath.ToSlash(relPath)
+		prev, next = s.pageNeighbors(relPath)
+		styleSheet = s.styleSheetURL(path.Dir(relPath))
+		pageURL = s.pageURL(r, relPath)
+		editURL = s.editURL(relPath)
+	}
+
+	// Pages using client-side features get a policy allowing their scripts
+	if s.config.EnableSecurityHeaders && rendered.Features != (pageFeatures{}) && !s.config.DisableCSP {
+		w.Header().Set("Content-Security-Policy", s.config.contentSecurityPolicy(rendered.Features))
+	}
+
+	// Pages without a description of their own are described by the site
+	description := pageDescription(meta, content)
+	if description == "" {
+		description = s.siteDescription()
+	}
+	words := wordCount(content)
+	image := pageImage(meta, pageURL)
+	data := pageData{
+		Title:            title,
+		Description:      description,
+		URL:              pageURL,
+		Image:            image,
+		OGType:           ogType(meta),
+		TwitterCard:      twitterCard(image),
+		Content:          template.HTML(rendered.HTML),
+		TOC:              template.HTML(rendered.TOC),
+		LastModified:     info.ModTime(),
+		StyleSheet:       styleSheet,
+		Prev:             prev,
+		Next:             next,
+		EditURL:          editURL,
+		Meta:             meta,
+		Draft:            draft,
+		Mermaid:          rendered.Features.Mermaid,
+		Math:             rendered.Features.Math,
+		InteractiveTasks: rendered.Features.TaskList && metaBool(meta, "interactive_tasks"),
+		WordCount:        words,
+		ReadingTime:      readingTime(words, s.config.ReadingWPM),
+	}
+	if s.config.StructuredData {
+		data.JSONLD = structuredData(data, info.ModTime())
+	}
+	if wantsPDF(r) {
+		s.servePDF(w, r, data)
+		return
+	}
+	s.renderPage(w, r, status, data)
+}
+
+// handleNotFound responds with the custom 404.md page if there is one,
+// otherwise with the plain-text default
+func (s *Server) handleNotFound(w http.ResponseWriter, r *http.Request) {
+	if !s.renderNotFoundPage(w, r) {
+		http.NotFound(w, r)
+	}
+}
+
+// renderNotFoundPage renders 404.md from the content directory with a 404 status.
+// It reports false, without writing anything, when there is no 404.md.
+func (s *Server) renderNotFoundPage(w http.ResponseWriter, r *http.Request) bool {
+	notFoundPath := filepath.Join(s.config.ContentDir, "404.md")
+	if info, err := os.Stat(notFoundPath); err != nil || info.IsDir() {
+		return false
+	}
+	s.serveMarkdownFile(w, r, notFoundPath, http.StatusNotFound)
+	return true
+}
+
+// pageTemplate is the HTML layout every page is rendered into
+const pageTemplate = `<!DOCTYPE html>
 <html lang="en">
 <head>
     <meta charset="UTF-8">
     <meta name="viewport" content="width=device-width, initial-scale=1.0">
     <title>{{.Title}}</title>
-    <link rel="stylesheet" href="/style.css">
+    {{- if .Description}}
+    <meta name="description" content="{{.Description}}">
+    {{- end}}
+    {{- if .URL}}
+    <link rel="canonical" href="{{.URL}}">
+    <meta property="og:site_name" content="{{.SiteName}}">
+    <meta property="og:title" content="{{.Title}}">
+    <meta property="og:type" content="{{.OGType}}">
+    <meta property="og:url" content="{{.URL}}">
+    {{- if .Description}}
+    <meta property="og:description" content="{{.Description}}">
+    {{- end}}
+    {{- if .Image}}
+    <meta property="og:image" content="{{.Image}}">
+    {{- end}}
+    <meta name="twitter:card" content="{{.TwitterCard}}">
+    <meta name="twitter:title" content="{{.Title}}">
+    {{- if .Description}}
+    <meta name="twitter:description" content="{{.Description}}">
+    {{- end}}
+    {{- if .Image}}
+    <meta name="twitter:image" content="{{.Image}}">
+    {{- end}}
+    {{- end}}
+    {{- if .JSONLD}}
+    <script type="application/ld+json">{{.JSONLD}}</script>
+    {{- end}}
+    <link rel="stylesheet" href="{{.StyleSheet}}">
+    <link rel="stylesheet" href="/__highlight.css">
+    <script src="/__theme.js"></script>
+    {{- if .Math}}
+    <link rel="stylesheet" href="https://cdn.jsdelivr.net/npm/katex@0.16.9/dist/katex.min.css">
+    <script defer src="https://cdn.jsdelivr.net/npm/katex@0.16.9/dist/katex.min.js"></script>
+    <script defer src="https://cdn.jsdelivr.net/npm/katex@0.16.9/dist/contrib/auto-render.min.js"></script>
+    <script defer src="/__katex.js"></script>
+    {{- end}}
 </head>
 <body>
-    <div class="container">
+    <div class="container{{if .NavTree}} has-sidebar{{end}}">
         <nav>
-            <a href="/">Home</a>
+            {{- range .Nav}}
+            <a href="{{.URL}}"{{if .Active}} class="active" aria-current="page"{{end}}>{{.Label}}</a>
+            {{- end}}
+            <button type="button" class="theme-toggle" aria-label="Toggle dark mode" hidden>&#9680;</button>
         </nav>
+        {{- if .Draft}}
+        <div class="draft-banner">Draft: this page is not published</div>
+        {{- end}}
+        {{- if .NavTree}}
+        <aside class="sidebar">
+            {{- template "navtree" .NavTree}}
+        </aside>
+        {{- end}}
         <main>
+            {{- if gt (len .Breadcrumbs) 1}}
+            <ol class="breadcrumbs" aria-label="Breadcrumb">
+                {{- range .Breadcrumbs}}
+                <li>{{if .URL}}<a href="{{.URL}}">{{.Label}}</a>{{else}}<span aria-current="page">{{.Label}}</span>{{end}}</li>
+                {{- end}}
+            </ol>
+            {{- end}}
+            {{- if .TOC}}
+            <div class="table-of-contents">{{.TOC}}</div>
+            {{- end}}
             {{.Content}}
+            {{- if .EditURL}}
+            <p class="edit-link"><a href="{{.EditURL}}">Edit this page</a></p>
+            {{- end}}
+            {{- if or .Prev .Next}}
+            <div class="page-nav">
+                {{- with .Prev}}
+                <a class="page-nav-prev" href="{{.URL}}" rel="prev">&larr; {{.Title}}</a>
+                {{- end}}
+                {{- with .Next}}
+                <a class="page-nav-next" href="{{.URL}}" rel="next">{{.Title}} &rarr;</a>
+                {{- end}}
+            </div>
+            {{- end}}
         </main>
     </div>
+    {{- if .Mermaid}}
+    <script type="module" src="/__mermaid.js"></script>
+    {{- end}}
+    {{- if .InteractiveTasks}}
+    <script src="/__tasks.js"></script>
+    {{- end}}
+    {{- if .LiveReload}}
+    <script src="/__livereload.js"></script>
+    {{- end}}
 </body>
-</html>`
-	
-	t, err := template.New("page").Parse(tmpl)
+</html>
+{{- define "navtree"}}
+<ul>
+    {{- range .}}
+    <li>
+        {{- if .URL}}<a href="{{.URL}}"{{if .Active}} class="active" aria-current="page"{{end}}>{{.Title}}</a>{{else}}<span>{{.Title}}</span>{{end}}
+        {{- if .Children}}{{template "navtree" .Children}}{{end}}
+    </li>
+    {{- end}}
+</ul>
+{{- end}}`
+
+// pageData is the data available to the page template
+type pageData struct {
+	Title      string
+	Content    template.HTML
+	LiveReload bool
+	// Description summarizes the page for <meta name="description">
+	Description string
+	// URL is the page's absolute URL, Image its frontmatter image and OGType
+	// its Open Graph type, for link previews. URL is empty on generated pages.
+	URL    string
+	Image  string
+	OGType string
+	// TwitterCard is summary_large_image for pages with an image, otherwise summary
+	TwitterCard string
+	// SiteName is SITE_TITLE, for og:site_name
+	SiteName string
+	// JSONLD is the page's schema.org structured data when STRUCTURED_DATA is enabled
+	JSONLD template.JS
+	// BasePath is the mount prefix for links to site-wide pages and assets
+	BasePath string
+	// StyleSheet is the URL of the style.css nearest to the page
+	StyleSheet string
+	// TOC is the page's table of contents when GENERATE_TOC is enabled
+	TOC template.HTML
+	// Nav is the navigation menu, with the current page marked active
+	Nav []navLink
+	// LastModified is the page file's modification time, zero for generated pages
+	LastModified time.Time
+	// Prev and Next are the neighbouring pages in reading order, nil at either end
+	Prev *pageLink
+	Next *pageLink
+	// EditURL links to the page's source file when EDIT_BASE_URL is set
+	EditURL string
+	// Breadcrumbs is the trail from the home page to the current page
+	Breadcrumbs []breadcrumb
+	// NavTree is the sidebar tree of pages and directories when SIDEBAR is enabled
+	NavTree []navNode
+	// Meta holds the page's frontmatter, e.g. {{.Meta.description}}
+	Meta map[string]interface{}
+	// Draft marks an unpublished page shown in preview mode
+	Draft bool
+	// Mermaid is set when the page contains diagrams, to load the Mermaid script
+	Mermaid bool
+	// Math is set when the page contains math, to load KaTeX
+	Math bool
+	// WordCount is the number of words of prose on the page, and ReadingTime
+	// the estimated minutes to read them at READING_WPM
+	WordCount   int
+	ReadingTime int
+	// InteractiveTasks makes the page's task list checkboxes clickable
+	InteractiveTasks bool
+}
+
+// renderPage renders data with the page template and writes it to the response
+// with the given status code
+func (s *Server) renderPage(w http.ResponseWriter, r *http.Request, status int, data pageData) {
+	// Render fully before writing so a template error can still become a 500
+	page, err := s.executePage(r, data)
 	if err != nil {
-		http.Error(w, "Template error", http.StatusInternalServerError)
-		return
-	}
-	
-	data := struct {
-		Title   string
-		Content template.HTML
-	}{
-		Title:   s.extractTitle(string(content)),
-		Content: template.HTML(htmlContent),
-	}
-	
-	w.Header().Set("Content-Type", "text/html")
-	if err := t.Execute(w, data); err != nil {
 		http.Error(w, "Template execution error", http.StatusInternalServerError)
 		return
 	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if status < http.StatusBadRequest {
+		w.Header().Set("Cache-Control", s.config.CacheControl)
+	}
+
+	// Pages are validated by their rendered content as well as their file's
+	// modification time, so generated pages can be revalidated too
+	if status == http.StatusOK {
+		w.Header().Set("ETag", contentETag(page))
+		fresh := notModified(w, r, time.Time{})
+		if !data.LastModified.IsZero() {
+			fresh = setLastModified(w, r, data.LastModified)
+		}
+		if fresh {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+	}
+
+	// HEAD gets the same headers as GET, including the length, but no body
+	w.Header().Set("Content-Length", strconv.Itoa(len(page)))
+	w.WriteHeader(status)
+	if r.Method != http.MethodHead {
+		w.Write(page)
+	}
 }
 
-func (s *Server) markdownToHTML(md []byte) string {
+// executePage fills in the data shared by every page, such as the navigation,
+// and renders it with the page template
+func (s *Server) executePage(r *http.Request, data pageData) ([]byte, error) {
+	// Prefer the external template when one is configured
+	t := s.templates.get()
+
+	data.LiveReload = s.liveReload != nil
+	data.BasePath = s.urlPrefix
+	data.SiteName = s.config.SiteTitle
+	data.Nav = s.navLinks(r)
+	data.Breadcrumbs = s.breadcrumbs(r)
+	if data.StyleSheet == "" {
+		data.StyleSheet = s.styleSheetURL(".")
+	}
+	if s.config.Sidebar {
+		data.NavTree = s.navTree(r)
+	}
+
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// renderBufferPool reuses output buffers between renders. gomarkdown parsers and
+// renderers keep per-document state (link references, unique heading IDs) that
+// cannot be reset, so they are still created fresh for every document.
+var renderBufferPool = sync.Pool{
+	New: func() interface{} { return new(bytes.Buffer) },
+}
+
+// pageFeatures records which client-side features a rendered page needs
+type pageFeatures struct {
+	Mermaid  bool
+	Math     bool
+	TaskList bool
+}
+
+// renderedMarkdown is the result of rendering a markdown document
+type renderedMarkdown struct {
+	HTML string
+	// TOC is the table of contents, set when GENERATE_TOC is enabled
+	TOC      string
+	Features pageFeatures
+}
+
+// markdownToHTML renders markdown to HTML, along with its table of contents and
+// the client-side features the page uses
+func (s *Server) markdownToHTML(md []byte, title, filePath string) renderedMarkdown {
 	// Create markdown parser with extensions
-	extensions := parser.CommonExtensions | parser.AutoHeadingIDs
+	extensions := markdownExtensions
+	if s.config.DisableMath {
+		extensions &^= parser.MathJax
+	}
 	p := parser.NewWithExtensions(extensions)
-	
-	// Create HTML renderer with options
-	htmlFlags := html.CommonFlags | html.HrefTargetBlank
-	opts := html.RendererOptions{Flags: htmlFlags}
-	renderer := html.NewRenderer(opts)
-	
-	// Parse and render
-	doc := p.Parse(md)
-	return string(markdown.Render(doc, renderer))
+	terms := make(map[*ast.Paragraph]bool)
+	p.Opts.ParserHook = definitionTermsHook(extensions, terms)
+
+	// Create HTML renderer with the server's precomputed options
+	renderer := html.NewRenderer(s.rendererOpts)
+
+	// Splice in included files, then parse the document
+	doc := p.Parse(s.expandIncludes(md, filePath))
+	splitDefinitionTerms(doc, terms)
+	if s.config.SingleH1 != "" {
+		s.enforceSingleH1(doc, title, filePath)
+	}
+	if s.config.CrossRefs {
+		s.resolveRefs(doc)
+	}
+	if s.config.WikiLinks {
+		s.convertWikiLinks(doc)
+	}
+	s.rewriteMarkdownLinks(doc, filePath)
+	if s.config.EnableEmoji {
+		replaceEmojiShortcodes(doc)
+	}
+	var result renderedMarkdown
+	result.Features.TaskList = markTaskListItems(doc)
+
+	// Build the table of contents for the layout and any inline [TOC] markers
+	toc := renderTOC(collectTOC(doc))
+	if s.config.GenerateTOC {
+		result.TOC = toc
+	}
+	replaceTOCMarkers(doc, toc)
+
+	// Render into a pooled buffer
+	buf := renderBufferPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	defer renderBufferPool.Put(buf)
+
+	renderer.RenderHeader(buf, doc)
+	ast.WalkFunc(doc, func(node ast.Node, entering bool) ast.WalkStatus {
+		if isMermaidBlock(node) {
+			result.Features.Mermaid = true
+		}
+		if isMathNode(node) {
+			result.Features.Math = true
+		}
+		return renderer.RenderNode(buf, node, entering)
+	})
+	renderer.RenderFooter(buf, doc)
+	result.HTML = buf.String()
+	return result
+}
+
+// renderNodeHook overrides the default HTML rendering of individual nodes. It
+// returns false for nodes that should be rendered normally.
+func (s *Server) renderNodeHook(w io.Writer, node ast.Node, entering bool) (ast.WalkStatus, bool) {
+	if link, ok := node.(*ast.Link); ok && s.config.CrossRefs && bytes.HasPrefix(link.Destination, refPrefix) {
+		renderUnresolvedRef(w, link, entering)
+		return ast.GoToNext, true
+	}
+	if heading, ok := node.(*ast.Heading); ok && !entering && s.config.HeadingAnchors && heading.HeadingID != "" {
+		renderHeadingAnchor(w, heading)
+		return ast.GoToNext, false
+	}
+	if isTaskListItem(node) {
+		renderTaskListItem(w, entering)
+		return ast.GoToNext, true
+	}
+	if isMermaidBlock(node) {
+		renderMermaid(w, node)
+		return ast.GoToNext, true
+	}
+	if block, ok := node.(*ast.CodeBlock); ok && block.IsFenced && s.renderHighlightedCode(w, block) {
+		return ast.GoToNext, true
+	}
+	return ast.GoToNext, false
+}
+
+// defaultTitle is used for pages without an H1 heading
+const defaultTitle = "Markdown Server"
+
+// titleForFile returns the title of the page at filePath. A frontmatter title
+// wins over the first H1. Pages with neither fall back to a title derived from
+// the filename when single H1 enforcement is enabled.
+func (s *Server) titleForFile(meta map[string]interface{}, body []byte, filePath string) string {
+	title := s.pageTitle(meta, body)
+	if s.config.SingleH1 != "" && title == defaultTitle {
+		if relPath, err := filepath.Rel(s.config.ContentDir, filePath); err == nil {
+			title = s.titleFromFilename(filepath.ToSlash(relPath))
+		}
+	}
+	return title
+}
+
+// pageTitle returns the frontmatter title if there is one, otherwise the first H1
+func (s *Server) pageTitle(meta map[string]interface{}, body []byte) string {
+	if title := metaString(meta, "title"); title != "" {
+		return title
+	}
+	return s.extractTitle(string(body))
 }
 
 func (s *Server) extractTitle(content string) string {
@@ -207,7 +880,7 @@ func (s *Server) extractTitle(content string) string {
 			return strings.TrimPrefix(line, "# ")
 		}
 	}
-	return "Markdown Server"
+	return defaultTitle
 }
 
 func (s *Server) ensureSampleContent() error {
@@ -215,12 +888,12 @@ func (s *Server) ensureSampleContent() error {
 	if err := s.ensureIndexFile(); err != nil {
 		return err
 	}
-	
+
 	// Create style.css file if it doesn't exist
 	if err := s.ensureStyleFile(); err != nil {
 		return err
 	}
-	
+
 	return nil
 }
 
@@ -230,10 +903,10 @@ func (s *Server) ensureIndexFile() error {
 	if err != nil {
 		return err
 	}
-	
+
 	if isEmpty {
 		// Create sample index.md file
-		indexPath := filepath.Join(s.contentDir, "index.md")
+		indexPath := filepath.Join(s.config.ContentDir, s.indexFile)
 		sampleContent := `# Welcome to the Markdown Server
 
 This is a sample markdown file that demonstrates the functionality of our Go-based markdown server.
@@ -280,17 +953,17 @@ Visit [GitHub](https://github.com) for more projects.
 `
 
 		if err := os.WriteFile(indexPath, []byte(sampleContent), 0644); err != nil {
-			return fmt.Errorf("failed to create sample index.md: %w", err)
+			return fmt.Errorf("failed to create sample %s: %w", s.indexFile, err)
 		}
-		
-		fmt.Printf("Created sample index.md file at %s\n", indexPath)
+
+		slog.Info("created sample page", "path", indexPath)
 	}
-	
+
 	return nil
 }
 
 func (s *Server) ensureStyleFile() error {
-	cssPath := filepath.Join(s.contentDir, "style.css")
+	cssPath := filepath.Join(s.config.ContentDir, "style.css")
 	if _, err := os.Stat(cssPath); os.IsNotExist(err) {
 		cssContent := `/* Reset and base styles */
 * {
@@ -319,9 +992,10 @@ func (s *Server) ensureStyleFile() error {
     --shadow: rgba(0, 0, 0, 0.1);
 }
 
-/* Dark mode variables - automatically applied when user prefers dark mode */
+/* Dark mode variables - applied when the user prefers dark mode, unless light
+   was picked with the theme toggle */
 @media (prefers-color-scheme: dark) {
-    :root {
+    :root:not([data-theme="light"]) {
         --bg-color: #1a1a1a;
         --container-bg: #2d2d2d;
         --text-color: #e0e0e0;
@@ -341,6 +1015,26 @@ func (s *Server) ensureStyleFile() error {
     }
 }
 
+/* Dark mode picked with the theme toggle */
+:root[data-theme="dark"] {
+    --bg-color: #1a1a1a;
+    --container-bg: #2d2d2d;
+    --text-color: #e0e0e0;
+    --heading-color: #ffffff;
+    --heading-secondary: #b0b0b0;
+    --nav-bg: #1f1f1f;
+    --nav-text: #ffffff;
+    --nav-accent: #4fc3f7;
+    --link-color: #4fc3f7;
+    --link-hover: #81d4fa;
+    --code-bg: #3a3a3a;
+    --border-color: #555;
+    --table-bg: #3a3a3a;
+    --blockquote-bg: #3a3a3a;
+    --hr-color: #555;
+    --shadow: rgba(0, 0, 0, 0.3);
+}
+
 body {
     font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, Oxygen, Ubuntu, Cantarell, sans-serif;
     line-height: 1.6;
@@ -374,7 +1068,30 @@ nav a {
     transition: color 0.3s ease;
 }
 
-nav a:hover {
+nav a:hover,
+nav a.active {
+    color: var(--nav-accent);
+}
+
+nav a + a {
+    margin-left: 1.5rem;
+}
+
+/* Theme toggle, switching between light and dark mode */
+.theme-toggle {
+    float: right;
+    background: none;
+    border: 1px solid var(--nav-text);
+    border-radius: 4px;
+    color: var(--nav-text);
+    cursor: pointer;
+    font-size: 1rem;
+    line-height: 1;
+    padding: 0.3rem 0.5rem;
+}
+
+.theme-toggle:hover {
+    border-color: var(--nav-accent);
     color: var(--nav-accent);
 }
 
@@ -475,6 +1192,24 @@ blockquote {
     transition: background-color 0.3s ease, border-color 0.3s ease;
 }
 
+/* Definition lists */
+dl {
+    margin-bottom: 1rem;
+}
+
+dt {
+    font-weight: 600;
+    color: var(--heading-secondary);
+}
+
+dd {
+    margin: 0 0 0.75rem 1.5rem;
+}
+
+dd p {
+    margin-bottom: 0.5rem;
+}
+
 /* Horizontal rules */
 hr {
     border: none;
@@ -521,6 +1256,126 @@ em {
     transition: color 0.3s ease;
 }
 
+/* Sidebar navigation */
+.has-sidebar {
+    display: grid;
+    grid-template-columns: 16rem minmax(0, 1fr);
+}
+
+.has-sidebar > nav,
+.has-sidebar > .draft-banner {
+    grid-column: 1 / -1;
+}
+
+.sidebar {
+    padding: 2rem 1rem;
+    border-right: 1px solid var(--border-color);
+}
+
+.sidebar ul {
+    list-style: none;
+}
+
+.sidebar ul ul {
+    margin-left: 1rem;
+}
+
+.sidebar a.active {
+    font-weight: 600;
+}
+
+/* Breadcrumbs */
+.breadcrumbs {
+    display: flex;
+    flex-wrap: wrap;
+    list-style: none;
+    margin-bottom: 1.5rem;
+    font-size: 0.9rem;
+}
+
+.breadcrumbs li + li::before {
+    content: "/";
+    margin: 0 0.5rem;
+    color: var(--border-color);
+}
+
+/* Previous and next page links */
+.page-nav {
+    display: flex;
+    justify-content: space-between;
+    gap: 1rem;
+    margin-top: 3rem;
+    padding-top: 1.5rem;
+    border-top: 1px solid var(--border-color);
+}
+
+.page-nav-next {
+    margin-left: auto;
+    text-align: right;
+}
+
+/* Table of contents */
+ul.toc {
+    margin-bottom: 1.5rem;
+}
+
+.toc ul {
+    margin-left: 1.5rem;
+}
+
+/* Heading permalinks (HEADING_ANCHORS), shown on hover and keyboard focus */
+.heading-anchor {
+    margin-left: 0.3em;
+    color: var(--heading-secondary);
+    text-decoration: none;
+    opacity: 0;
+}
+
+h1:hover > .heading-anchor,
+h2:hover > .heading-anchor,
+h3:hover > .heading-anchor,
+h4:hover > .heading-anchor,
+h5:hover > .heading-anchor,
+h6:hover > .heading-anchor,
+.heading-anchor:focus {
+    opacity: 1;
+}
+
+/* Link to the page's source (EDIT_BASE_URL) */
+.edit-link {
+    margin-top: 2rem;
+    font-size: 0.9rem;
+}
+
+/* Wiki links to pages that don't exist */
+a.broken {
+    color: #c0392b;
+    text-decoration: underline dotted;
+}
+
+/* Task lists */
+li.task-list-item {
+    list-style-type: none;
+}
+
+li.task-list-item > p:first-child {
+    display: inline;
+}
+
+.task-list-checkbox {
+    margin: 0 0.4em 0 -1.4em;
+    vertical-align: middle;
+}
+
+/* Draft banner shown in preview mode */
+.draft-banner {
+    padding: 0.75rem 2rem;
+    background-color: #f39c12;
+    color: #1a1a1a;
+    font-weight: 600;
+    text-align: center;
+}
+
 /* Responsive design */
 @media (max-width: 768px) {
     .container {
@@ -528,6 +1383,15 @@ em {
         box-shadow: none;
     }
     
+    .has-sidebar {
+        display: block;
+    }
+    
+    .sidebar {
+        border-right: none;
+        border-bottom: 1px solid var(--border-color);
+    }
+    
     nav {
         padding: 1rem;
     }
@@ -552,29 +1416,29 @@ em {
 		if err := os.WriteFile(cssPath, []byte(cssContent), 0644); err != nil {
 			return fmt.Errorf("failed to create sample style.css: %w", err)
 		}
-		
-		fmt.Printf("Created sample style.css file at %s\n", cssPath)
+
+		slog.Info("created sample stylesheet", "path", cssPath)
 	}
-	
+
 	return nil
 }
 
 func (s *Server) isContentDirEmpty() (bool, error) {
-	entries, err := os.ReadDir(s.contentDir)
+	entries, err := os.ReadDir(s.config.ContentDir)
 	if err != nil {
 		if os.IsNotExist(err) {
 			return true, nil
 		}
 		return false, err
 	}
-	
+
 	// Check if there are any .md files
 	for _, entry := range entries {
 		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".md") {
 			return false, nil
 		}
 	}
-	
+
 	return true, nil
 }
 
@@ -587,7 +1451,7 @@ func (s *Server) validatePath(path string) error {
 		strings.Contains(path, "\\") {
 		return fmt.Errorf("invalid path: contains dangerous characters")
 	}
-	
+
 	// Only allow alphanumeric, dash, underscore, dot, and slash
 	for _, char := range path {
 		if !((char >= 'a' && char <= 'z') ||
@@ -597,62 +1461,89 @@ func (s *Server) validatePath(path string) error {
 			return fmt.Errorf("invalid path: contains invalid characters")
 		}
 	}
-	
+
 	return nil
 }
 
+// isHiddenPath reports whether any segment of the path is a dotfile or dot-directory
+func isHiddenPath(path string) bool {
+	for _, segment := range strings.Split(path, "/") {
+		if strings.HasPrefix(segment, ".") {
+			return true
+		}
+	}
+	return false
+}
+
+// cleanURL converts a markdown file path relative to the content directory into
+// its extension-less URL, e.g. "docs/setup.md" becomes "/docs/setup" and
+// "docs/index.md", or the DEFAULT_DOCUMENT, becomes "/docs/"
+func (s *Server) cleanURL(relPath string) string {
+	relPath = strings.TrimSuffix(relPath, ".md")
+	indexName := strings.TrimSuffix(s.indexFile, ".md")
+	if relPath == indexName {
+		return "/"
+	}
+	if strings.HasSuffix(relPath, "/"+indexName) {
+		return "/" + strings.TrimSuffix(relPath, indexName)
+	}
+	return "/" + relPath
+}
+
 // isPathSafe ensures the resolved path is within the content directory
 func (s *Server) isPathSafe(requestedPath string) bool {
 	// Get absolute paths
-	contentAbs, err := filepath.Abs(s.contentDir)
+	contentAbs, err := filepath.Abs(s.config.ContentDir)
 	if err != nil {
 		return false
 	}
-	
+
 	requestedAbs, err := filepath.Abs(requestedPath)
 	if err != nil {
 		return false
 	}
-	
+
 	// Check if the requested path is within the content directory
 	rel, err := filepath.Rel(contentAbs, requestedAbs)
 	if err != nil {
 		return false
 	}
-	
+
 	// If the relative path starts with "..", it's outside the content directory
 	return !strings.HasPrefix(rel, "..")
 }
 
 func main() {
-	contentDir := os.Getenv("CONTENT_DIR")
-	if contentDir == "" {
-		contentDir = "./content"
-	}
-	
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
-	}
-	
-	// Check if security headers should be enabled (default: enabled)
-	enableSecurityHeaders := true
-	if securityHeadersEnv := os.Getenv("HTTP_SECURITY_HEADERS"); securityHeadersEnv == "disable" {
-		enableSecurityHeaders = false
-		fmt.Println("HTTP security headers disabled")
-	}
-	
-	// Create content directory if it doesn't exist
-	if err := os.MkdirAll(contentDir, 0755); err != nil {
-		log.Fatal("Failed to create content directory:", err)
-	}
-	
-	server := NewServer(contentDir, port, enableSecurityHeaders)
-	
-	// Ensure sample content exists if directory is empty
-	if err := server.ensureSampleContent(); err != nil {
-		log.Printf("Warning: Failed to create sample content: %v", err)
-	}
-	
-	log.Fatal(server.Start())
-}
\ No newline at end of file
+	// Defaults, config file, environment variables and flags, in increasing precedence
+	config, err := loadConfig(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
+	}
+	if err != nil {
+		fatal("invalid configuration", err)
+	}
+	level, _ := config.logLevel()
+	slog.SetDefault(newLogger(config.LogFormat, level))
+	config.logSummary()
+
+	server, err := NewServer(config)
+	if err != nil {
+		fatal("failed to start server", err)
+	}
+
+	for _, contentServer := range server.contentServers() {
+		// Create content directory if it doesn't exist
+		if err := os.MkdirAll(contentServer.config.ContentDir, 0755); err != nil {
+			fatal("failed to create content directory", err)
+		}
+
+		// Ensure sample content exists if directory is empty
+		if err := contentServer.ensureSampleContent(); err != nil {
+			slog.Warn("failed to create sample content", "err", err)
+		}
+	}
+
+	if err := server.Start(); err != nil {
+		fatal("server stopped", err)
+	}
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestMain silences the warnings pages under test log on purpose
+func TestMain(m *testing.M) {
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	os.Exit(m.Run())
+}
+
+// newTestServer writes files, keyed by slash-separated path, into a temporary
+// content directory and returns a server for it. configure, if set, adjusts
+// the default configuration before the server is created.
+func newTestServer(t *testing.T, files map[string]string, configure func(*Config)) *Server {
+	t.Helper()
+	dir := t.TempDir()
+	writeFiles(t, dir, files)
+
+	config := defaultConfig()
+	config.ContentDir = dir
+	if configure != nil {
+		configure(&config)
+	}
+	if err := config.validate(); err != nil {
+		t.Fatalf("invalid test config: %v", err)
+	}
+	s, err := NewServer(config)
+	if err != nil {
+		t.Fatalf("NewServer: %v", err)
+	}
+	s.accessLog = slog.New(slog.NewTextHandler(io.Discard, nil))
+	t.Cleanup(s.close)
+	return s
+}
+
+// writeFiles creates files, keyed by slash-separated path, under dir
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// serve sends a request through the server's middleware and router
+func serve(s *Server, r *http.Request) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	s.handler().ServeHTTP(w, r)
+	return w
+}
+
+// get sends a GET request for target through the server's middleware and router
+func get(s *Server, target string) *httptest.ResponseRecorder {
+	return serve(s, httptest.NewRequest(http.MethodGet, target, nil))
+}
+
+// benchmarkPage is a typical page with headings, lists, code and links
+const benchmarkPage = `---
+title: Benchmark
+---
+
+# Benchmark
+
+Some *emphasis*, a [link](other.md) and ` + "`code`" + `.
+
+## Lists
+
+- one
+- two
+- three
+
+` + "```go\nfunc main() {}\n```" + `
+
+| a | b |
+|---|---|
+| 1 | 2 |
+`
+
+func TestMarkdownToHTMLReusesBuffers(t *testing.T) {
+	s := newTestServer(t, nil, nil)
+	first := s.markdownToHTML([]byte("# First\n\nAlpha"), "First", "first.md")
+	want := first.HTML
+	for i := 0; i < 10; i++ {
+		s.markdownToHTML([]byte("# Second\n\nBeta gamma delta"), "Second", "second.md")
+	}
+	if first.HTML != want {
+		t.Errorf("rendered HTML changed after later renders: %q, want %q", first.HTML, want)
+	}
+}
+
+func TestMarkdownToHTMLConcurrent(t *testing.T) {
+	s := newTestServer(t, nil, nil)
+	want := s.markdownToHTML([]byte(benchmarkPage), "Benchmark", "bench.md").HTML
+
+	done := make(chan string)
+	for i := 0; i < 8; i++ {
+		go func() {
+			done <- s.markdownToHTML([]byte(benchmarkPage), "Benchmark", "bench.md").HTML
+		}()
+	}
+	for i := 0; i < 8; i++ {
+		if got := <-done; got != want {
+			t.Errorf("concurrent render differs:\n%s\nwant:\n%s", got, want)
+		}
+	}
+}
+
+func BenchmarkMarkdownToHTML(b *testing.B) {
+	config := defaultConfig()
+	config.ContentDir = b.TempDir()
+	s, err := NewServer(config)
+	if err != nil {
+		b.Fatal(err)
+	}
+	page := []byte(benchmarkPage)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		s.markdownToHTML(page, "Benchmark", "bench.md")
+	}
+}
+
+func TestNotFoundPage(t *testing.T) {
+	notFound := "---\ntitle: Nothing Here\n---\n\nTry the [home page](index.md).\n"
+	tests := []struct {
+		name   string
+		files  map[string]string
+		path   string
+		status int
+		want   string
+	}{
+		{"custom page", map[string]string{"index.md": "# Home\n", "404.md": notFound}, "/missing", http.StatusNotFound, "<title>Nothing Here"},
+		{"custom page in subdirectory", map[string]string{"404.md": notFound}, "/guide/missing", http.StatusNotFound, "<title>Nothing Here"},
+		{"plain response", nil, "/missing", http.StatusNotFound, "404 page not found"},
+		{"draft custom page", map[string]string{"404.md": "---\ndraft: true\n---\n"}, "/missing", http.StatusNotFound, "404 page not found"},
+		{"existing page", map[string]string{"page.md": "---\ntitle: Page\n---\n", "404.md": notFound}, "/page", http.StatusOK, "<title>Page"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServer(t, tt.files, nil)
+			w := get(s, tt.path)
+			if w.Code != tt.status {
+				t.Errorf("status = %d, want %d", w.Code, tt.status)
+			}
+			if !strings.Contains(w.Body.String(), tt.want) {
+				t.Errorf("body does not contain %q:\n%s", tt.want, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/gomarkdown/markdown/ast"
+	"github.com/gomarkdown/markdown/html"
+)
+
+// mermaidScript is served from /__mermaid.js as a module so it runs under the
+// script-src Content Security Policy without an inline script
+const mermaidScript = `import mermaid from "` + cdnOrigin + `/npm/mermaid@10/dist/mermaid.esm.min.mjs";
+
+const theme = document.documentElement.dataset.theme;
+const dark = theme ? theme === "dark" : window.matchMedia("(prefers-color-scheme: dark)").matches;
+mermaid.initialize({ startOnLoad: true, theme: dark ? "dark" : "default" });
+`
+
+// isMermaidBlock reports whether node is a fenced code block with the mermaid info string
+func isMermaidBlock(node ast.Node) bool {
+	block, ok := node.(*ast.CodeBlock)
+	if !ok {
+		return false
+	}
+	fields := bytes.Fields(block.Info)
+	return len(fields) > 0 && string(fields[0]) == "mermaid"
+}
+
+// renderMermaid writes a diagram's source into a div for Mermaid to render in
+// the browser. The source is escaped; Mermaid reads it back as text.
+func renderMermaid(w io.Writer, node ast.Node) {
+	io.WriteString(w, `<div class="mermaid">`)
+	html.EscapeHTML(w, node.AsLeaf().Literal)
+	io.WriteString(w, "</div>\n")
+}
+
+func (s *Server) handleMermaidScript(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/javascript")
+	w.Header().Set("Cache-Control", s.config.CacheControl)
+	fmt.Fprint(w, mermaidScript)
+}
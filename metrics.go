@@ -0,0 +1,108 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// metricsPath serves Prometheus metrics when ENABLE_METRICS is set. Like the
+// health probes it never requires authentication.
+const metricsPath = "/metrics"
+
+// metrics are the Prometheus collectors exported on /metrics
+type metrics struct {
+	registry     *prometheus.Registry
+	requests     *prometheus.CounterVec
+	duration     *prometheus.HistogramVec
+	cacheLookups *prometheus.CounterVec
+}
+
+// newMetrics registers the server's collectors, along with the standard Go
+// runtime and process collectors, on a dedicated registry
+func newMetrics(s *Server) *metrics {
+	m := &metrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "markdown_server_requests_total",
+			Help: "HTTP requests served, by status code.",
+		}, []string{"code"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "markdown_server_request_duration_seconds",
+			Help:    "Time taken to serve HTTP requests, by method.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"method"}),
+		cacheLookups: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "markdown_server_cache_lookups_total",
+			Help: "Lookups in the generated feed and search index caches, by cache and result.",
+		}, []string{"cache", "result"}),
+	}
+	cacheSize := prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "markdown_server_cache_bytes",
+		Help: "Size of the generated feeds and search index held in memory.",
+	}, func() float64 {
+		return float64(s.jsonFeedCache.size() + s.xmlFeedCache.size() + s.atomFeedCache.size() + s.searchIndexCache.size())
+	})
+	searchIndexSize := prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "markdown_server_search_index_bytes",
+		Help: "Approximate size of the in-memory search indexes.",
+	}, func() float64 {
+		size := 0
+		for _, server := range s.contentServers() {
+			size += server.search.size()
+		}
+		return float64(size)
+	})
+
+	m.registry.MustRegister(
+		m.requests,
+		m.duration,
+		m.cacheLookups,
+		cacheSize,
+		searchIndexSize,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return m
+}
+
+// observeCache returns a callback counting hits and misses of the named cache
+func (m *metrics) observeCache(name string) func(hit bool) {
+	return func(hit bool) {
+		result := "miss"
+		if hit {
+			result = "hit"
+		}
+		m.cacheLookups.WithLabelValues(name, result).Inc()
+	}
+}
+
+// handleMetrics serves the metrics in the Prometheus exposition format
+func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	promhttp.HandlerFor(s.metrics.registry, promhttp.HandlerOpts{}).ServeHTTP(w, r)
+}
+
+// metricsMiddleware counts every request by status code and records how long
+// it took. It does nothing when metrics are disabled.
+func (s *Server) metricsMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	if s.metrics == nil {
+		return next
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rw := &responseWriter{ResponseWriter: w}
+
+		next(rw, r)
+
+		status := rw.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		s.metrics.requests.WithLabelValues(strconv.Itoa(status)).Inc()
+		s.metrics.duration.WithLabelValues(r.Method).Observe(time.Since(start).Seconds())
+	}
+}
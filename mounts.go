@@ -0,0 +1,126 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strings"
+)
+
+// Mount maps a URL prefix to its own content directory. Index optionally
+// names the file served for the mount's directories instead of index.md, and
+// EditBaseURL replaces EDIT_BASE_URL for the mount's pages.
+type Mount struct {
+	Prefix      string `yaml:"prefix" toml:"prefix"`
+	Dir         string `yaml:"dir" toml:"dir"`
+	Index       string `yaml:"index" toml:"index"`
+	EditBaseURL string `yaml:"edit_base_url" toml:"edit_base_url"`
+}
+
+// mountedServer serves one mount. It is a full Server rooted at the mount's
+// directory whose generated links carry the mount prefix.
+type mountedServer struct {
+	prefix string
+	server *Server
+}
+
+// parseMounts parses a comma-separated list of prefix=directory pairs,
+// e.g. "/docs=./docs-content,/blog=./blog-content"
+func parseMounts(value string) ([]Mount, error) {
+	var mounts []Mount
+	for _, pair := range strings.Split(value, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		prefix, dir, found := strings.Cut(pair, "=")
+		if !found {
+			return nil, fmt.Errorf("invalid mount %q: expected prefix=directory", pair)
+		}
+		mounts = append(mounts, Mount{Prefix: strings.TrimSpace(prefix), Dir: strings.TrimSpace(dir)})
+	}
+	return mounts, nil
+}
+
+// validateMounts checks that every mount has an absolute prefix and a directory,
+// and that no prefix is used twice
+func validateMounts(mounts []Mount) error {
+	seen := make(map[string]bool)
+	for _, m := range mounts {
+		if !strings.HasPrefix(m.Prefix, "/") || (m.Prefix != "/" && strings.HasSuffix(m.Prefix, "/")) {
+			return fmt.Errorf("invalid mount prefix %q: must start with / and not end with /", m.Prefix)
+		}
+		if m.Dir == "" {
+			return fmt.Errorf("mount %s has no directory", m.Prefix)
+		}
+		if m.Index != "" && (filepath.Ext(m.Index) != ".md" || m.Index != filepath.Base(m.Index)) {
+			return fmt.Errorf("invalid index %q for mount %s: must be a .md file name", m.Index, m.Prefix)
+		}
+		if m.EditBaseURL != "" && !validEditBaseURL(m.EditBaseURL) {
+			return fmt.Errorf("invalid edit_base_url %q for mount %s: must be an http or https URL", m.EditBaseURL, m.Prefix)
+		}
+		if seen[m.Prefix] {
+			return fmt.Errorf("duplicate mount prefix %q", m.Prefix)
+		}
+		seen[m.Prefix] = true
+	}
+	return nil
+}
+
+// newMountedServers creates a server for each configured mount. Mounts share
+// the parent's configuration apart from the content directory.
+func (s *Server) newMountedServers() ([]mountedServer, error) {
+	var mounted []mountedServer
+	for _, m := range s.config.Mounts {
+		config := s.config
+		config.ContentDir = m.Dir
+		config.Mounts = nil
+		config.EnableMetrics = false // requests and caches are counted by the parent
+		config.BasicAuthFile = ""    // credentials are checked by the parent
+		if m.EditBaseURL != "" {
+			config.EditBaseURL = m.EditBaseURL
+		}
+
+		server, err := NewServer(config)
+		if err != nil {
+			return nil, err
+		}
+		server.liveReload = s.liveReload
+		server.templates = s.templates
+		server.pdfSlots = s.pdfSlots
+		server.urlPrefix = strings.TrimSuffix(m.Prefix, "/")
+		if m.Index != "" {
+			server.indexFile = m.Index
+		}
+		mounted = append(mounted, mountedServer{prefix: m.Prefix, server: server})
+	}
+	return mounted, nil
+}
+
+// matchMount returns the mount with the longest prefix matching path
+func (s *Server) matchMount(path string) (mountedServer, bool) {
+	var best mountedServer
+	found := false
+	for _, m := range s.mounts {
+		matches := m.prefix == "/" || path == m.prefix || strings.HasPrefix(path, m.prefix+"/")
+		if matches && (!found || len(m.prefix) > len(best.prefix)) {
+			best, found = m, true
+		}
+	}
+	return best, found
+}
+
+// handleMounts dispatches a request to the mount with the longest matching
+// prefix, stripping the prefix so the mount resolves the remaining path within
+// its own directory. Requests that match no mount get a 404.
+func (s *Server) handleMounts(w http.ResponseWriter, r *http.Request) {
+	m, ok := s.matchMount(r.URL.Path)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	mountReq := r.Clone(r.Context())
+	mountReq.URL.Path = "/" + strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, m.server.urlPrefix), "/")
+	m.server.handleMarkdown(w, mountReq)
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// navFiles are the files, in order of preference, that define the navigation menu
+var navFiles = []string{"nav.yaml", "nav.yml", "nav.json"}
+
+// navItem is a navigation menu entry as written in the nav file
+type navItem struct {
+	Label string `yaml:"label" json:"label"`
+	URL   string `yaml:"url" json:"url"`
+}
+
+// navLink is a navigation menu entry passed to the page template
+type navLink struct {
+	Label  string
+	URL    string
+	Active bool
+}
+
+// loadNav reads the navigation menu from the content directory. Without a nav
+// file the menu is left empty and pages show only a Home link.
+func (s *Server) loadNav() error {
+	for _, name := range navFiles {
+		path := filepath.Join(s.config.ContentDir, name)
+		data, err := os.ReadFile(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+
+		var items []navItem
+		if filepath.Ext(name) == ".json" {
+			err = json.Unmarshal(data, &items)
+		} else {
+			err = yaml.Unmarshal(data, &items)
+		}
+		if err != nil {
+			return fmt.Errorf("invalid navigation file %s: %w", path, err)
+		}
+		for i, item := range items {
+			if item.Label == "" || item.URL == "" {
+				return fmt.Errorf("invalid navigation file %s: entry %d needs a label and a url", path, i+1)
+			}
+		}
+		s.nav = items
+		return nil
+	}
+	return nil
+}
+
+// navLinks returns the navigation menu for a request, marking the entry for
+// the current page as active
+func (s *Server) navLinks(r *http.Request) []navLink {
+	items := s.nav
+	if items == nil {
+		items = []navItem{{Label: "Home", URL: s.urlPrefix + "/"}}
+	}
+
+	current := normalizeNavPath(s.urlPrefix + r.URL.Path)
+	links := make([]navLink, len(items))
+	for i, item := range items {
+		links[i] = navLink{
+			Label:  item.Label,
+			URL:    item.URL,
+			Active: normalizeNavPath(item.URL) == current,
+		}
+	}
+	return links
+}
+
+// normalizeNavPath makes equivalent page paths comparable by dropping the
+// .md extension and any trailing slash, e.g. "/docs/" and "/docs.md" both
+// become "/docs"
+func normalizeNavPath(path string) string {
+	path = strings.TrimSuffix(path, ".md")
+	if path != "/" {
+		path = strings.TrimSuffix(path, "/")
+	}
+	return path
+}
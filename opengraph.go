@@ -0,0 +1,58 @@
+package main
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Open Graph object types (https://ogp.me/#types)
+const (
+	ogTypeWebsite = "website"
+	ogTypeArticle = "article"
+)
+
+// pageURL returns the absolute URL of the page at relPath, in the form
+// CANONICAL_URLS redirects to, for link previews and <link rel="canonical">
+func (s *Server) pageURL(r *http.Request, relPath string) string {
+	urlPath := s.cleanURL(relPath)
+	if s.config.CanonicalURLs == canonicalSlash && !strings.HasSuffix(urlPath, "/") {
+		urlPath += "/"
+	}
+	return s.requestBaseURL(r) + s.urlPrefix + urlPath
+}
+
+// pageImage returns the absolute URL of the image frontmatter key, resolved
+// against the page's URL so paths relative to the page work, or "" if unset
+func pageImage(meta map[string]interface{}, pageURL string) string {
+	image := metaString(meta, "image")
+	if image == "" {
+		return ""
+	}
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		return image
+	}
+	ref, err := url.Parse(image)
+	if err != nil {
+		return image
+	}
+	return base.ResolveReference(ref).String()
+}
+
+// twitterCard returns the Twitter card type: a large image when the page has
+// one, otherwise a summary
+func twitterCard(image string) string {
+	if image != "" {
+		return "summary_large_image"
+	}
+	return "summary"
+}
+
+// ogType returns "article" for dated posts and "website" for other pages
+func ogType(meta map[string]interface{}) string {
+	if _, ok := metaDate(meta, "date"); ok {
+		return ogTypeArticle
+	}
+	return ogTypeWebsite
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// orderFile lists a directory's pages in reading order, one filename per line
+const orderFile = "order.txt"
+
+// pageLink is a link to a neighbouring page
+type pageLink struct {
+	Title string
+	URL   string
+}
+
+// orderedPage is a page in reading order
+type orderedPage struct {
+	relPath  string
+	title    string
+	weight   int
+	weighted bool
+}
+
+// pageNeighbors returns the pages before and after relPath in reading order.
+// Pages only link to pages in the same directory unless PAGE_NAV_ACROSS_DIRS
+// is enabled, in which case the whole site is read depth-first.
+func (s *Server) pageNeighbors(relPath string) (prev, next *pageLink) {
+	var pages []orderedPage
+	if s.config.PageNavAcrossDirs {
+		pages = s.sitePageOrder("")
+	} else {
+		pages = s.dirPageOrder(path.Dir(relPath))
+	}
+
+	for i, page := range pages {
+		if page.relPath != relPath {
+			continue
+		}
+		if i > 0 {
+			prev = &pageLink{Title: pages[i-1].title, URL: s.urlPrefix + s.cleanURL(pages[i-1].relPath)}
+		}
+		if i < len(pages)-1 {
+			next = &pageLink{Title: pages[i+1].title, URL: s.urlPrefix + s.cleanURL(pages[i+1].relPath)}
+		}
+		break
+	}
+	return prev, next
+}
+
+// sitePageOrder returns the pages of dirRel followed by those of each
+// subdirectory in turn
+func (s *Server) sitePageOrder(dirRel string) []orderedPage {
+	pages := s.dirPageOrder(dirRel)
+	entries, err := s.readContentDir(dirRel)
+	if err != nil {
+		return pages
+	}
+	for _, entry := range entries {
+		if entry.IsDir() && !strings.HasPrefix(entry.Name(), ".") {
+			pages = append(pages, s.sitePageOrder(path.Join(dirRel, entry.Name()))...)
+		}
+	}
+	return pages
+}
+
+// dirPageOrder returns the pages in a directory in reading order: index.md
+// first, then the pages listed in order.txt, then pages with a frontmatter
+// weight (lowest first) and finally the rest by filename. Hidden pages,
+// the error pages and unpublished drafts are left out.
+func (s *Server) dirPageOrder(dirRel string) []orderedPage {
+	dir := filepath.Join(s.config.ContentDir, filepath.FromSlash(dirRel))
+	if !s.isPathSafe(dir) {
+		return nil
+	}
+	entries, err := s.readContentDir(dirRel)
+	if err != nil {
+		return nil
+	}
+
+	var pages []orderedPage
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || strings.HasPrefix(name, ".") || filepath.Ext(name) != ".md" || name == "404.md" || name == "500.md" {
+			continue
+		}
+		file, ok := s.treeFile(path.Join(dirRel, name))
+		if !ok || !s.isPublicFile(file.relPath) {
+			continue
+		}
+		page := orderedPage{relPath: file.relPath, title: file.page.title, weight: file.page.weight, weighted: file.page.weighted}
+		if page.title == "" {
+			page.title = s.titleFromFilename(page.relPath)
+		}
+		pages = append(pages, page)
+	}
+
+	listed := readOrderFile(filepath.Join(dir, orderFile))
+	rank := func(page orderedPage) int {
+		name := path.Base(page.relPath)
+		if name == s.indexFile {
+			return -1
+		}
+		if i, ok := listed[name]; ok {
+			return i
+		}
+		return len(listed)
+	}
+	sort.SliceStable(pages, func(i, j int) bool {
+		a, b := pages[i], pages[j]
+		if rankA, rankB := rank(a), rank(b); rankA != rankB {
+			return rankA < rankB
+		}
+		if a.weighted != b.weighted {
+			return a.weighted
+		}
+		if a.weight != b.weight {
+			return a.weight < b.weight
+		}
+		return a.relPath < b.relPath
+	})
+	return pages
+}
+
+// readOrderFile returns the position of each page named in an order.txt file.
+// Names may omit the .md extension; blank lines and # comments are ignored.
+func readOrderFile(path string) map[string]int {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	listed := map[string]int{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		name := strings.TrimSpace(scanner.Text())
+		if name == "" || strings.HasPrefix(name, "#") {
+			continue
+		}
+		name = strings.TrimSuffix(name, ".md") + ".md"
+		if _, ok := listed[name]; !ok {
+			listed[name] = len(listed)
+		}
+	}
+	return listed
+}
@@ -0,0 +1,68 @@
+package main
+
+import "testing"
+
+// pageNavFixtures orders guide/ by weight and tutorial/ by order.txt
+var pageNavFixtures = map[string]string{
+	"index.md":             "# Home\n",
+	"about.md":             "---\ntitle: About\n---\n",
+	"guide/index.md":       "---\ntitle: Guide\n---\n",
+	"guide/alpha.md":       "---\ntitle: Alpha\n---\n",
+	"guide/beta.md":        "---\ntitle: Beta\nweight: 1\n---\n",
+	"guide/gamma.md":       "---\ntitle: Gamma\nweight: 2\n---\n",
+	"guide/draft.md":       "---\ntitle: Draft\ndraft: true\nweight: 0\n---\n",
+	"tutorial/order.txt":   "# reading order\nsecond\nfirst.md\n",
+	"tutorial/first.md":    "---\ntitle: First\n---\n",
+	"tutorial/second.md":   "---\ntitle: Second\n---\n",
+	"tutorial/appendix.md": "---\ntitle: Appendix\n---\n",
+}
+
+func TestPageNeighbors(t *testing.T) {
+	tests := []struct {
+		name     string
+		across   bool
+		relPath  string
+		wantPrev string
+		wantNext string
+	}{
+		{"index first", false, "guide/index.md", "", "/guide/beta"},
+		{"weights", false, "guide/beta.md", "/guide/", "/guide/gamma"},
+		{"unweighted last", false, "guide/gamma.md", "/guide/beta", "/guide/alpha"},
+		{"last page", false, "guide/alpha.md", "/guide/gamma", ""},
+		{"order file first", false, "tutorial/second.md", "", "/tutorial/first"},
+		{"unlisted after order file", false, "tutorial/appendix.md", "/tutorial/first", ""},
+		{"not across directories", false, "about.md", "/", ""},
+		{"across directories", true, "about.md", "/", "/guide/"},
+		{"across into next directory", true, "guide/alpha.md", "/guide/gamma", "/tutorial/second"},
+		{"across last page", true, "tutorial/appendix.md", "/tutorial/first", ""},
+		{"across first page", true, "index.md", "", "/about"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServer(t, pageNavFixtures, func(c *Config) { c.PageNavAcrossDirs = tt.across })
+			prev, next := s.pageNeighbors(tt.relPath)
+			if got := linkURL(prev); got != tt.wantPrev {
+				t.Errorf("prev = %q, want %q", got, tt.wantPrev)
+			}
+			if got := linkURL(next); got != tt.wantNext {
+				t.Errorf("next = %q, want %q", got, tt.wantNext)
+			}
+		})
+	}
+}
+
+func TestPageNeighborTitles(t *testing.T) {
+	s := newTestServer(t, pageNavFixtures, nil)
+	prev, next := s.pageNeighbors("guide/gamma.md")
+	if prev == nil || prev.Title != "Beta" || next == nil || next.Title != "Alpha" {
+		t.Errorf("neighbors = %+v, %+v, want Beta and Alpha", prev, next)
+	}
+}
+
+// linkURL returns the URL of link, or "" if there is none
+func linkURL(link *pageLink) string {
+	if link == nil {
+		return ""
+	}
+	return link.URL
+}
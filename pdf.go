@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"html"
+	"log/slog"
+	"mime"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// defaultPDFConcurrency is how many PDF exports may run at once
+	defaultPDFConcurrency = 2
+	// pdfTimeout bounds a single conversion so a stuck browser can't hold a slot
+	pdfTimeout = time.Minute
+)
+
+// headTag matches the opening <head> tag of a rendered page
+var headTag = regexp.MustCompile(`(?i)<head(\s[^>]*)?>`)
+
+// wantsPDF reports whether the client asked for the page as a PDF with ?format=pdf
+func wantsPDF(r *http.Request) bool {
+	return r.URL.Query().Get("format") == "pdf"
+}
+
+// servePDF renders the page and converts it to a PDF download with
+// PDF_CONVERTER. Conversions are limited to PDF_CONCURRENCY at a time; requests
+// beyond that are turned away with 503 rather than queued.
+func (s *Server) servePDF(w http.ResponseWriter, r *http.Request, data pageData) {
+	if s.config.PDFConverter == "" {
+		http.Error(w, "PDF export is not configured", http.StatusNotImplemented)
+		return
+	}
+	select {
+	case s.pdfSlots <- struct{}{}:
+		defer func() { <-s.pdfSlots }()
+	default:
+		w.Header().Set("Retry-After", "5")
+		http.Error(w, "Too many PDF exports in progress", http.StatusServiceUnavailable)
+		return
+	}
+
+	page, err := s.executePage(r, data)
+	if err != nil {
+		http.Error(w, "Template execution error", http.StatusInternalServerError)
+		return
+	}
+
+	// The converter loads the page from a file, so point relative URLs such as
+	// the stylesheet and images back at the server
+	base := data.URL
+	if base == "" {
+		base = s.requestBaseURL(r) + r.URL.Path
+	}
+	if loc := headTag.FindIndex(page); loc != nil {
+		var buf bytes.Buffer
+		buf.Write(page[:loc[1]])
+		buf.WriteString(`<base href="` + html.EscapeString(base) + `">`)
+		buf.Write(page[loc[1]:])
+		page = buf.Bytes()
+	}
+
+	// Conversions may take longer than WRITE_TIMEOUT allows a response
+	if s.config.WriteTimeout > 0 {
+		http.NewResponseController(w).SetWriteDeadline(time.Now().Add(pdfTimeout + seconds(s.config.WriteTimeout)))
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), pdfTimeout)
+	defer cancel()
+	pdf, err := s.convertToPDF(ctx, page)
+	if err != nil {
+		slog.Error("PDF export failed", "path", r.URL.Path, "err", err)
+		http.Error(w, "PDF export failed", http.StatusInternalServerError)
+		return
+	}
+
+	filename := slugify(data.Title)
+	if filename == "" {
+		filename = "page"
+	}
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename + ".pdf"}))
+	w.Header().Set("Content-Length", strconv.Itoa(len(pdf)))
+	w.Header().Set("Cache-Control", "no-store")
+	if r.Method != http.MethodHead {
+		w.Write(pdf)
+	}
+}
+
+// convertToPDF runs PDF_CONVERTER on the rendered page. wkhtmltopdf is called
+// with input and output files; anything else is assumed to be Chromium or
+// Chrome and run headless with --print-to-pdf.
+func (s *Server) convertToPDF(ctx context.Context, page []byte) ([]byte, error) {
+	dir, err := os.MkdirTemp("", "markdown-pdf-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	input := filepath.Join(dir, "page.html")
+	output := filepath.Join(dir, "page.pdf")
+	if err := os.WriteFile(input, page, 0600); err != nil {
+		return nil, err
+	}
+
+	var args []string
+	if strings.Contains(filepath.Base(s.config.PDFConverter), "wkhtmltopdf") {
+		args = []string{"--quiet", input, output}
+	} else {
+		args = []string{"--headless", "--disable-gpu", "--no-pdf-header-footer",
+			"--user-data-dir=" + filepath.Join(dir, "profile"), "--print-to-pdf=" + output, "file://" + input}
+	}
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, s.config.PDFConverter, args...)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("%s: %w: %s", s.config.PDFConverter, err, strings.TrimSpace(stderr.String()))
+	}
+	return os.ReadFile(output)
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+
+	"github.com/gomarkdown/markdown/ast"
+	"github.com/gomarkdown/markdown/parser"
+)
+
+// markdownText returns a markdown document as plain text: headings and
+// paragraphs become lines separated by blank lines, list items become bullets
+// and links and images are replaced by their text. Code blocks are kept
+// verbatim when withCode is set and left out otherwise; raw HTML is always
+// left out.
+func markdownText(body []byte, withCode bool) string {
+	doc := parser.NewWithExtensions(markdownExtensions).Parse(body)
+	t := &textWriter{withCode: withCode}
+	t.blocks(doc, "")
+	return strings.TrimSpace(t.buf.String())
+}
+
+// textWriter accumulates the plain text of a document for markdownText
+type textWriter struct {
+	buf      strings.Builder
+	withCode bool
+}
+
+// blocks writes the block-level children of node
+func (t *textWriter) blocks(node ast.Node, indent string) {
+	for _, child := range node.GetChildren() {
+		t.block(child, indent)
+	}
+}
+
+// block writes a block followed by a blank line, with every line indented
+func (t *textWriter) block(node ast.Node, indent string) {
+	switch node := node.(type) {
+	case *ast.Heading, *ast.Paragraph:
+		if text := inlineText(node); text != "" {
+			t.buf.WriteString(indent)
+			t.lines(indent, text)
+			t.buf.WriteByte('\n')
+		}
+	case *ast.List:
+		t.list(node, indent)
+		t.buf.WriteByte('\n')
+	case *ast.CodeBlock:
+		if t.withCode {
+			t.buf.WriteString(indent)
+			t.lines(indent, strings.TrimRight(string(node.Literal), "\n"))
+			t.buf.WriteByte('\n')
+		}
+	case *ast.MathBlock:
+		if t.withCode {
+			t.buf.WriteString(indent)
+			t.lines(indent, strings.TrimSpace(string(node.Literal)))
+			t.buf.WriteByte('\n')
+		}
+	case *ast.Table:
+		t.table(node, indent)
+		t.buf.WriteByte('\n')
+	case *ast.HTMLBlock, *ast.HorizontalRule:
+	default:
+		// Block quotes, footnotes and other containers are written as their contents
+		t.blocks(node, indent)
+	}
+}
+
+// list writes one line per item, "- " for bullets and "1. " for numbered
+// items. Nested lists and further paragraphs are indented under their item,
+// and definition list terms are written as they are with their definitions
+// indented below.
+func (t *textWriter) list(list *ast.List, indent string) {
+	number := list.Start
+	if number == 0 {
+		number = 1
+	}
+	for _, child := range list.Children {
+		item, ok := child.(*ast.ListItem)
+		if !ok {
+			continue
+		}
+		marker := "- "
+		switch {
+		case item.ListFlags&ast.ListTypeTerm != 0:
+			marker = ""
+		case list.ListFlags&ast.ListTypeDefinition != 0:
+			marker = "  "
+		case list.ListFlags&ast.ListTypeOrdered != 0:
+			marker = strconv.Itoa(number) + ". "
+			number++
+		}
+
+		prefix := indent + marker
+		nested := indent + strings.Repeat(" ", len(marker))
+		for i, block := range item.Children {
+			switch block := block.(type) {
+			case *ast.Paragraph:
+				// Paragraphs of a loose item are separated like top-level ones
+				if i > 0 {
+					if _, ok := item.Children[i-1].(*ast.Paragraph); ok {
+						t.buf.WriteByte('\n')
+					}
+				}
+				t.buf.WriteString(prefix)
+				t.lines(nested, inlineText(block))
+			case *ast.List:
+				t.list(block, nested)
+			default:
+				t.block(block, nested)
+			}
+			prefix = nested
+		}
+	}
+}
+
+// table writes one line per row with the cells separated by tabs
+func (t *textWriter) table(node ast.Node, indent string) {
+	ast.WalkFunc(node, func(child ast.Node, entering bool) ast.WalkStatus {
+		row, ok := child.(*ast.TableRow)
+		if !ok || !entering {
+			return ast.GoToNext
+		}
+		var cells []string
+		for _, cell := range row.Children {
+			cells = append(cells, strings.ReplaceAll(inlineText(cell), "\n", " "))
+		}
+		t.buf.WriteString(indent + strings.Join(cells, "\t") + "\n")
+		return ast.SkipChildren
+	})
+}
+
+// lines writes text and a newline, indenting every line after the first by
+// indent. The first line follows whatever prefix the caller has written.
+func (t *textWriter) lines(indent, text string) {
+	t.buf.WriteString(strings.ReplaceAll(text, "\n", "\n"+indent))
+	t.buf.WriteByte('\n')
+}
+
+// inlineText returns the text of an inline container with formatting
+// removed. Soft line breaks and runs of spaces are collapsed, while hard
+// line breaks are kept as newlines.
+func inlineText(node ast.Node) string {
+	var buf bytes.Buffer
+	ast.WalkFunc(node, func(child ast.Node, entering bool) ast.WalkStatus {
+		switch child := child.(type) {
+		case *ast.Text:
+			buf.Write(bytes.ReplaceAll(child.Literal, []byte("\n"), []byte(" ")))
+		case *ast.Code, *ast.Math:
+			buf.Write(child.AsLeaf().Literal)
+		case *ast.Hardbreak:
+			buf.WriteByte('\n')
+		}
+		return ast.GoToNext
+	})
+
+	lines := strings.Split(buf.String(), "\n")
+	for i, line := range lines {
+		lines[i] = strings.Join(strings.Fields(line), " ")
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gomarkdown/markdown/ast"
+)
+
+// paragraph returns a paragraph node containing text
+func paragraph(text string) *ast.Paragraph {
+	p := &ast.Paragraph{}
+	ast.AppendChild(p, &ast.Text{Leaf: ast.Leaf{Literal: []byte(text)}})
+	return p
+}
+
+// list returns a list node with one item per child, each wrapped in a list
+// item unless it is one already
+func list(flags ast.ListType, children ...ast.Node) *ast.List {
+	l := &ast.List{ListFlags: flags}
+	for _, child := range children {
+		item, ok := child.(*ast.ListItem)
+		if !ok {
+			item = &ast.ListItem{}
+			ast.AppendChild(item, child)
+		}
+		ast.AppendChild(l, item)
+	}
+	return l
+}
+
+func TestMarkdownText(t *testing.T) {
+	source := "# Getting Started\n\nRead the [install guide](install.md)\nbefore you begin.\n\n" +
+		"```sh\nmake install\n```\n\n<div class=\"note\">\nskipped\n</div>\n\n![Diagram](arch.png)\n"
+	tests := []struct {
+		name     string
+		withCode bool
+		want     string
+	}{
+		{"with code", true, "Getting Started\n\nRead the install guide before you begin.\n\nmake install\n\nDiagram"},
+		{"without code", false, "Getting Started\n\nRead the install guide before you begin.\n\nDiagram"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := markdownText([]byte(source), tt.withCode); got != tt.want {
+				t.Errorf("markdownText =\n%q\nwant\n%q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTextWriterLists(t *testing.T) {
+	loose := &ast.ListItem{}
+	ast.AppendChild(loose, paragraph("First paragraph"))
+	ast.AppendChild(loose, paragraph("Second paragraph"))
+	numbered := list(ast.ListTypeOrdered, paragraph("Three"), paragraph("Four"))
+	numbered.Start = 3
+	nested := &ast.ListItem{}
+	ast.AppendChild(nested, paragraph("Parent"))
+	ast.AppendChild(nested, list(0, paragraph("Child")))
+
+	tests := []struct {
+		name string
+		doc  *ast.Document
+		want string
+	}{
+		{"bullets", document(list(0, paragraph("One"), paragraph("Two"))), "- One\n- Two"},
+		{"numbered", document(list(ast.ListTypeOrdered, paragraph("One"), paragraph("Two"))), "1. One\n2. Two"},
+		{"start number", document(numbered), "3. Three\n4. Four"},
+		{"nested", document(list(0, nested)), "- Parent\n  - Child"},
+		{"loose item", document(list(0, loose)), "- First paragraph\n\n  Second paragraph"},
+		{"blocks separated", document(heading(2, "Steps"), list(0, paragraph("One")), paragraph("Done.")), "Steps\n\n- One\n\nDone."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := &textWriter{}
+			w.blocks(tt.doc, "")
+			if got := strings.TrimRight(w.buf.String(), "\n"); got != tt.want {
+				t.Errorf("text =\n%q\nwant\n%q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPlainTextFormat(t *testing.T) {
+	s := newTestServer(t, map[string]string{
+		"guide.md": "---\ntitle: Guide\n---\n\n# Guide\n\nSee [the docs](docs.md).\n",
+		"gone.md":  "---\nstatus: 410\n---\n\n# Gone\n",
+	}, nil)
+	tests := []struct {
+		path   string
+		status int
+		want   string
+	}{
+		{"/guide?format=txt", http.StatusOK, "Guide\n\nSee the docs.\n"},
+		{"/gone?format=txt", http.StatusGone, "Gone\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			w := get(s, tt.path)
+			if w.Code != tt.status {
+				t.Errorf("status = %d, want %d", w.Code, tt.status)
+			}
+			if got := w.Header().Get("Content-Type"); got != "text/plain; charset=utf-8" {
+				t.Errorf("Content-Type = %q", got)
+			}
+			if w.Body.String() != tt.want {
+				t.Errorf("body = %q, want %q", w.Body.String(), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// precompressedEncodings are the variants looked for next to a static file,
+// e.g. style.css.br and style.css.gz, in order of preference
+var precompressedEncodings = []struct {
+	encoding string
+	ext      string
+}{
+	{"br", ".br"},
+	{"gzip", ".gz"},
+}
+
+// servePrecompressed serves a precompressed variant of the file name from open
+// when the client accepts its encoding, so static files compressed at build
+// time aren't compressed again on every request. The Content-Type is that of
+// the original file; callers set it when they know it. It reports whether it
+// responded, and otherwise leaves the raw file to the caller.
+func servePrecompressed(w http.ResponseWriter, r *http.Request, open func(name string) (http.File, error), name string) bool {
+	var chosen http.File
+	var encoding string
+	found := false
+	for _, variant := range precompressedEncodings {
+		f, err := open(name + variant.ext)
+		if err != nil {
+			continue
+		}
+		if info, err := f.Stat(); err != nil || info.IsDir() {
+			f.Close()
+			continue
+		}
+		found = true
+		if chosen == nil && acceptsEncoding(r, variant.encoding) {
+			chosen, encoding = f, variant.encoding
+		} else {
+			f.Close()
+		}
+	}
+	if found {
+		// Caches must tell clients that accept the variants apart from those
+		// that don't, whichever one this response is
+		w.Header().Add("Vary", "Accept-Encoding")
+	}
+	if chosen == nil {
+		return false
+	}
+	defer chosen.Close()
+
+	info, err := chosen.Stat()
+	if err != nil {
+		return false
+	}
+	if w.Header().Get("Content-Type") == "" {
+		w.Header().Set("Content-Type", sniffContentType(open, name))
+	}
+	w.Header().Set("Content-Encoding", encoding)
+	http.ServeContent(w, r, name, info.ModTime(), chosen)
+	return true
+}
+
+// sniffContentType returns the content type of the original, uncompressed file
+// name, by extension or from its first bytes
+func sniffContentType(open func(name string) (http.File, error), name string) string {
+	if contentType := assetContentType(path.Ext(name)); contentType != "" {
+		return contentType
+	}
+	f, err := open(name)
+	if err != nil {
+		return "application/octet-stream"
+	}
+	defer f.Close()
+	buf := make([]byte, 512)
+	n, _ := io.ReadFull(f, buf)
+	return http.DetectContentType(buf[:n])
+}
+
+// acceptsEncoding reports whether the request's Accept-Encoding header allows
+// encoding, by name or with *, and not with q=0
+func acceptsEncoding(r *http.Request, encoding string) bool {
+	accepted, wildcard := false, false
+	for _, part := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		name, params, _ := strings.Cut(part, ";")
+		name = strings.TrimSpace(name)
+		allowed := true
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if weight, err := strconv.ParseFloat(q, 64); err == nil && weight == 0 {
+				allowed = false
+			}
+		}
+		switch {
+		case strings.EqualFold(name, encoding):
+			// An explicit entry overrides the wildcard
+			return allowed
+		case name == "*":
+			accepted, wildcard = allowed, true
+		}
+	}
+	return wildcard && accepted
+}
+
+// openContent opens a file in the content directory by its slash-separated
+// path, refusing paths that resolve outside it
+func (s *Server) openContent(name string) (http.File, error) {
+	filePath := filepath.Join(s.config.ContentDir, filepath.FromSlash(name))
+	if !s.isPathSafe(filePath) {
+		return nil, fs.ErrPermission
+	}
+	return os.Open(filePath)
+}
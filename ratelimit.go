@@ -0,0 +1,132 @@
+package main
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+const (
+	// defaultRateLimitBurst is how many requests a client may make at once
+	// before RATE_LIMIT applies
+	defaultRateLimitBurst = 20
+	// rateLimitIdle is how long a client's limiter is kept after its last request
+	rateLimitIdle = 3 * time.Minute
+)
+
+// rateLimiter keeps a token bucket per client IP address
+type rateLimiter struct {
+	mu       sync.Mutex
+	limit    rate.Limit
+	burst    int
+	visitors map[string]*visitor
+}
+
+// visitor is a client's token bucket and when it was last used
+type visitor struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// newRateLimiter returns a limiter allowing each client limit requests per
+// second on average and burst at once. Clients idle for a few minutes are
+// dropped in the background, until done is closed, so the map doesn't grow
+// without bound.
+func newRateLimiter(limit float64, burst int, done <-chan struct{}) *rateLimiter {
+	rl := &rateLimiter{
+		limit:    rate.Limit(limit),
+		burst:    burst,
+		visitors: make(map[string]*visitor),
+	}
+	go rl.cleanupLoop(time.Minute, done)
+	return rl
+}
+
+// cleanupLoop drops idle clients every interval until done is closed
+func (rl *rateLimiter) cleanupLoop(interval time.Duration, done <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			rl.cleanup(time.Now().Add(-rateLimitIdle))
+		case <-done:
+			return
+		}
+	}
+}
+
+// allow reports whether the client at ip may make another request now
+func (rl *rateLimiter) allow(ip string) bool {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	v, ok := rl.visitors[ip]
+	if !ok {
+		v = &visitor{limiter: rate.NewLimiter(rl.limit, rl.burst)}
+		rl.visitors[ip] = v
+	}
+	v.lastSeen = time.Now()
+	return v.limiter.Allow()
+}
+
+// cleanup drops the clients not seen since before
+func (rl *rateLimiter) cleanup(before time.Time) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	for ip, v := range rl.visitors {
+		if v.lastSeen.Before(before) {
+			delete(rl.visitors, ip)
+		}
+	}
+}
+
+// rateLimitMiddleware answers clients making more than RATE_LIMIT requests per
+// second, beyond RATE_LIMIT_BURST, with 429 Too Many Requests. The health
+// probes and metrics are never limited. It does nothing when RATE_LIMIT is 0.
+func (s *Server) rateLimitMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	if s.config.RateLimit <= 0 {
+		return next
+	}
+	limiter := newRateLimiter(s.config.RateLimit, s.config.RateLimitBurst, s.done)
+	// A token comes back every 1/RATE_LIMIT seconds
+	retryAfter := strconv.Itoa(int(math.Max(1, math.Ceil(1/s.config.RateLimit))))
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == healthzPath || r.URL.Path == readyzPath || r.URL.Path == metricsPath {
+			next(w, r)
+			return
+		}
+		if !limiter.allow(s.clientIP(r)) {
+			w.Header().Set("Retry-After", retryAfter)
+			http.Error(w, "Too Many Requests", http.StatusTooManyRequests)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// clientIP returns the address of the client making the request. Behind a
+// reverse proxy with TRUST_PROXY, that is the last address in X-Forwarded-For,
+// the one the proxy itself added; earlier ones are set by the client and can
+// be forged.
+func (s *Server) clientIP(r *http.Request) string {
+	if s.config.TrustProxy {
+		if forwarded := r.Header.Values("X-Forwarded-For"); len(forwarded) > 0 {
+			addrs := strings.Split(forwarded[len(forwarded)-1], ",")
+			if ip := strings.TrimSpace(addrs[len(addrs)-1]); ip != "" {
+				return ip
+			}
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
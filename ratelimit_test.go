@@ -0,0 +1,145 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRateLimitMiddleware(t *testing.T) {
+	tests := []struct {
+		name      string
+		limit     float64
+		burst     int
+		path      string
+		requests  int
+		limited   int
+		retryWant string
+	}{
+		{"disabled", 0, defaultRateLimitBurst, "/", 50, 0, ""},
+		{"within burst", 1, 5, "/", 5, 0, ""},
+		{"above burst", 1, 5, "/", 8, 3, "1"},
+		{"slow refill", 0.1, 2, "/", 4, 2, "10"},
+		{"probes exempt", 1, 1, healthzPath, 5, 0, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServer(t, map[string]string{"index.md": "# Home\n"}, func(c *Config) {
+				c.RateLimit, c.RateLimitBurst = tt.limit, tt.burst
+			})
+			handler := s.handler()
+			limited := 0
+			for i := 0; i < tt.requests; i++ {
+				w := httptest.NewRecorder()
+				handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+				if w.Code != http.StatusTooManyRequests {
+					continue
+				}
+				limited++
+				if got := w.Header().Get("Retry-After"); got != tt.retryWant {
+					t.Errorf("Retry-After = %q, want %q", got, tt.retryWant)
+				}
+			}
+			if limited != tt.limited {
+				t.Errorf("%d of %d requests limited, want %d", limited, tt.requests, tt.limited)
+			}
+		})
+	}
+}
+
+func TestRateLimitPerClient(t *testing.T) {
+	tests := []struct {
+		name       string
+		trustProxy bool
+		// second changes the request made once the first client's burst is used up
+		second func(r *http.Request)
+		status int
+	}{
+		{"same client", false, func(r *http.Request) {}, http.StatusTooManyRequests},
+		{"other address", false, func(r *http.Request) { r.RemoteAddr = "192.0.2.2:1234" }, http.StatusOK},
+		{"forwarded without trust", false, func(r *http.Request) {
+			r.Header.Set("X-Forwarded-For", "198.51.100.2")
+		}, http.StatusTooManyRequests},
+		{"forwarded through a trusted proxy", true, func(r *http.Request) {
+			r.Header.Set("X-Forwarded-For", "198.51.100.2")
+		}, http.StatusOK},
+		{"forged forwarded address", true, func(r *http.Request) {
+			r.Header.Set("X-Forwarded-For", "198.51.100.2, 198.51.100.1")
+		}, http.StatusTooManyRequests},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServer(t, map[string]string{"index.md": "# Home\n"}, func(c *Config) {
+				c.RateLimit, c.RateLimitBurst = 1, 1
+				c.TrustProxy = tt.trustProxy
+			})
+			handler := s.handler()
+
+			first := httptest.NewRequest(http.MethodGet, "/", nil)
+			first.RemoteAddr = "192.0.2.1:1234"
+			first.Header.Set("X-Forwarded-For", "198.51.100.1")
+			handler.ServeHTTP(httptest.NewRecorder(), first)
+
+			second := httptest.NewRequest(http.MethodGet, "/", nil)
+			second.RemoteAddr = "192.0.2.1:1234"
+			second.Header.Set("X-Forwarded-For", "198.51.100.1")
+			tt.second(second)
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, second)
+			if w.Code != tt.status {
+				t.Errorf("status = %d, want %d", w.Code, tt.status)
+			}
+		})
+	}
+}
+
+func TestRateLimiterCleanup(t *testing.T) {
+	rl := &rateLimiter{limit: 1, burst: 1, visitors: make(map[string]*visitor)}
+	rl.allow("192.0.2.1")
+	rl.allow("192.0.2.2")
+	rl.visitors["192.0.2.1"].lastSeen = time.Now().Add(-2 * rateLimitIdle)
+
+	rl.cleanup(time.Now().Add(-rateLimitIdle))
+	if _, ok := rl.visitors["192.0.2.1"]; ok {
+		t.Error("idle client kept")
+	}
+	if _, ok := rl.visitors["192.0.2.2"]; !ok {
+		t.Error("active client dropped")
+	}
+}
+
+func TestRateLimiterCleanupLoop(t *testing.T) {
+	rl := &rateLimiter{limit: 1, burst: 1, visitors: make(map[string]*visitor)}
+	rl.allow("192.0.2.1")
+	rl.mu.Lock()
+	rl.visitors["192.0.2.1"].lastSeen = time.Now().Add(-2 * rateLimitIdle)
+	rl.mu.Unlock()
+
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		rl.cleanupLoop(time.Millisecond, done)
+		close(stopped)
+	}()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		rl.mu.Lock()
+		n := len(rl.visitors)
+		rl.mu.Unlock()
+		if n == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("idle client not dropped")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	close(done)
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("cleanup still running after done was closed")
+	}
+}
@@ -0,0 +1,18 @@
+package main
+
+import "strings"
+
+// defaultReadingWPM is the reading speed assumed for reading time estimates
+const defaultReadingWPM = 200
+
+// wordCount counts the words of prose in a markdown body. Code blocks, HTML
+// tags and markdown syntax are not counted.
+func wordCount(body []byte) int {
+	return len(strings.Fields(stripMarkdown(body)))
+}
+
+// readingTime estimates the minutes needed to read words at wpm words per
+// minute, rounded up
+func readingTime(words, wpm int) int {
+	return (words + wpm - 1) / wpm
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestReadingTime(t *testing.T) {
+	tests := []struct {
+		words, wpm, want int
+	}{
+		{0, 200, 0},
+		{1, 200, 1},
+		{200, 200, 1},
+		{201, 200, 2},
+		{1000, 200, 5},
+		{1000, 250, 4},
+	}
+	for _, tt := range tests {
+		if got := readingTime(tt.words, tt.wpm); got != tt.want {
+			t.Errorf("readingTime(%d, %d) = %d, want %d", tt.words, tt.wpm, got, tt.want)
+		}
+	}
+}
+
+func TestWordCount(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want int
+	}{
+		{"empty", "", 0},
+		{"prose", "One two three.\n\nFour five.\n", 5},
+		{"heading", "# Title Here\n\nOne two.\n", 4},
+		{"code block ignored", "One two.\n\n```go\nfunc main() { fmt.Println(1) }\n```\n\nThree.\n", 3},
+		{"html ignored", "One two.\n\n<div class=\"note\">\n<span>skip</span>\n</div>\n\nThree.\n", 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := wordCount([]byte(tt.body)); got != tt.want {
+				t.Errorf("wordCount = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadingTimeInTemplate(t *testing.T) {
+	path := writeTemplate(t, "{{.WordCount}} words, {{.ReadingTime}} min read")
+	body := strings.Repeat("word ", 450) + "\n"
+	tests := []struct {
+		name string
+		wpm  int
+		want string
+	}{
+		{"default speed", defaultReadingWPM, "450 words, 3 min read"},
+		{"configured speed", 150, "450 words, 3 min read"},
+		{"fast reader", 500, "450 words, 1 min read"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServer(t, map[string]string{"post.md": body}, func(c *Config) {
+				c.TemplateFile = path
+				c.ReadingWPM = tt.wpm
+			})
+			w := get(s, "/post")
+			if w.Code != http.StatusOK || w.Body.String() != tt.want {
+				t.Errorf("got %d %q, want %q", w.Code, w.Body.String(), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+)
+
+// recoverMiddleware turns a panic in a handler into a 500 response with the
+// custom 500.md page, so a renderer bug on one malformed document fails only
+// that request. The stack trace is logged.
+func (s *Server) recoverMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		rw := &responseWriter{ResponseWriter: w}
+		defer func() {
+			err := recover()
+			if err == nil {
+				return
+			}
+			if err == http.ErrAbortHandler {
+				panic(err) // deliberately aborted, nothing to report
+			}
+			slog.Error("panic serving request", "path", r.URL.Path, "err", err, "stack", string(debug.Stack()))
+
+			// Once the response has started it can only be cut short
+			if rw.status != 0 {
+				return
+			}
+			s.handleServerError(w, r)
+		}()
+		next(rw, r)
+	}
+}
+
+// handleServerError responds with the custom 500.md page if there is one,
+// otherwise with a plain-text message
+func (s *Server) handleServerError(w http.ResponseWriter, r *http.Request) {
+	// Drop headers the failed handler may have set for its own response
+	for _, header := range []string{"Cache-Control", "Content-Length", "ETag", "Last-Modified", "Vary"} {
+		w.Header().Del(header)
+	}
+	w.Header().Set("Cache-Control", "no-store")
+
+	server := s
+	if m, ok := s.matchMount(r.URL.Path); ok {
+		server = m.server
+	}
+	if !server.renderErrorPage(w, r) {
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+	}
+}
+
+// renderErrorPage renders 500.md from the content directory with a 500 status.
+// It reports false, without writing anything, when there is no 500.md or
+// rendering it fails too.
+func (s *Server) renderErrorPage(w http.ResponseWriter, r *http.Request) (ok bool) {
+	errorPath := filepath.Join(s.config.ContentDir, "500.md")
+	if info, err := os.Stat(errorPath); err != nil || info.IsDir() {
+		return false
+	}
+
+	rw := &responseWriter{ResponseWriter: w}
+	defer func() {
+		if err := recover(); err != nil {
+			slog.Error("panic rendering 500.md", "err", err)
+			ok = rw.status != 0
+		}
+	}()
+	s.serveMarkdownFile(rw, r, errorPath, http.StatusInternalServerError)
+	return true
+}
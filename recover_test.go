@@ -0,0 +1,79 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRecoverMiddleware(t *testing.T) {
+	panicking := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "public, max-age=600")
+		panic("renderer bug")
+	}
+	tests := []struct {
+		name    string
+		files   map[string]string
+		handler http.HandlerFunc
+		status  int
+		want    string
+	}{
+		{"custom page", map[string]string{"500.md": "---\ntitle: Something Broke\n---\n\nSorry.\n"}, panicking, http.StatusInternalServerError, "<title>Something Broke"},
+		{"plain response", nil, panicking, http.StatusInternalServerError, "Internal Server Error"},
+		{"draft custom page", map[string]string{"500.md": "---\ndraft: true\n---\n"}, panicking, http.StatusInternalServerError, "Internal Server Error"},
+		{
+			"response already started",
+			nil,
+			func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte("partial"))
+				panic("late failure")
+			},
+			http.StatusOK,
+			"partial",
+		},
+		{
+			"no panic",
+			nil,
+			func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("fine")) },
+			http.StatusOK,
+			"fine",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServer(t, tt.files, nil)
+			w := httptest.NewRecorder()
+			s.securityHeadersMiddleware(s.recoverMiddleware(tt.handler))(w, httptest.NewRequest(http.MethodGet, "/page", nil))
+
+			if w.Code != tt.status {
+				t.Errorf("status = %d, want %d", w.Code, tt.status)
+			}
+			if !strings.Contains(w.Body.String(), tt.want) {
+				t.Errorf("body does not contain %q:\n%s", tt.want, w.Body.String())
+			}
+			if tt.status == http.StatusInternalServerError {
+				if got := w.Header().Get("Cache-Control"); got != "no-store" {
+					t.Errorf("Cache-Control = %q, want no-store", got)
+				}
+				if w.Header().Get("X-Content-Type-Options") == "" {
+					t.Error("security headers missing from the error response")
+				}
+			}
+		})
+	}
+}
+
+func TestRecoverMiddlewareAbort(t *testing.T) {
+	s := newTestServer(t, nil, nil)
+	handler := s.recoverMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	})
+	defer func() {
+		if err := recover(); err != http.ErrAbortHandler {
+			t.Errorf("recovered %v, want http.ErrAbortHandler re-panicked", err)
+		}
+	}()
+	handler(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// redirectsFile lists redirects for moved pages, one per line in Netlify's
+// format: "from to [status]". Lines starting with # are comments.
+const redirectsFile = "_redirects"
+
+// redirectStatuses are the status codes a redirect rule may use. Netlify's
+// rewrites (200) and custom 404s are not supported.
+var redirectStatuses = map[int]bool{
+	http.StatusMovedPermanently:  true,
+	http.StatusFound:             true,
+	http.StatusSeeOther:          true,
+	http.StatusTemporaryRedirect: true,
+	http.StatusPermanentRedirect: true,
+}
+
+// redirectRule sends requests for from to another URL. A from ending in /*
+// matches everything under that prefix, and :splat in the target is replaced
+// by the part of the path the * matched.
+type redirectRule struct {
+	from   string
+	to     string
+	status int
+	prefix bool
+}
+
+// loadRedirects reads the content directory's _redirects file, if there is one
+func (s *Server) loadRedirects() error {
+	path := filepath.Join(s.config.ContentDir, redirectsFile)
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	var rules []redirectRule
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		if len(fields) < 2 || len(fields) > 3 || !strings.HasPrefix(fields[0], "/") {
+			return fmt.Errorf("invalid redirect in %s on line %d: want \"/from to [status]\"", path, line)
+		}
+
+		rule := redirectRule{from: fields[0], to: fields[1], status: http.StatusMovedPermanently}
+		if len(fields) == 3 {
+			status, err := strconv.Atoi(strings.TrimSuffix(fields[2], "!"))
+			if err != nil || !redirectStatuses[status] {
+				return fmt.Errorf("invalid redirect in %s on line %d: unsupported status %q", path, line, fields[2])
+			}
+			rule.status = status
+		}
+		if strings.HasSuffix(rule.from, "/*") {
+			rule.from, rule.prefix = strings.TrimSuffix(rule.from, "*"), true
+		}
+		rules = append(rules, rule)
+	}
+	s.redirects = rules
+	return nil
+}
+
+// matchRedirect returns the target and status of the first rule matching
+// urlPath. Exact rules ignore a trailing slash, so /old and /old/ match alike.
+func (s *Server) matchRedirect(urlPath string) (string, int, bool) {
+	for _, rule := range s.redirects {
+		if rule.prefix {
+			if splat, ok := strings.CutPrefix(urlPath, rule.from); ok {
+				return strings.ReplaceAll(rule.to, ":splat", splat), rule.status, true
+			}
+			if urlPath+"/" == rule.from {
+				return strings.ReplaceAll(rule.to, ":splat", ""), rule.status, true
+			}
+			continue
+		}
+		if urlPath == rule.from || strings.TrimSuffix(urlPath, "/") == strings.TrimSuffix(rule.from, "/") {
+			return rule.to, rule.status, true
+		}
+	}
+	return "", 0, false
+}
+
+// redirect answers the request with a redirect if a _redirects rule matches
+// it. Site-relative targets are resolved within the mount, and the query
+// string is kept unless the target has its own.
+func (s *Server) redirect(w http.ResponseWriter, r *http.Request) bool {
+	target, status, ok := s.matchRedirect(r.URL.Path)
+	if !ok {
+		return false
+	}
+	if strings.HasPrefix(target, "/") && !strings.HasPrefix(target, "//") {
+		target = s.urlPrefix + target
+	}
+	if r.URL.RawQuery != "" && !strings.Contains(target, "?") {
+		target += "?" + r.URL.RawQuery
+	}
+	http.Redirect(w, r, target, status)
+	return true
+}
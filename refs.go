@@ -0,0 +1,119 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/gomarkdown/markdown/ast"
+)
+
+// refPrefix marks a link destination as a cross-reference, e.g. [see](ref:setup-auth)
+var refPrefix = []byte("ref:")
+
+// explicitHeadingID matches headings with an explicit ID, e.g. "## Setup {#setup-auth}"
+var explicitHeadingID = regexp.MustCompile(`^#{1,6}\s.*\{#([A-Za-z0-9_-]+)\}$`)
+
+// refIndex maps reference IDs to the page URL (and anchor) they point at
+type refIndex struct {
+	mu   sync.RWMutex
+	refs map[string]string
+}
+
+func (idx *refIndex) lookup(id string) (string, bool) {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	url, ok := idx.refs[id]
+	return url, ok
+}
+
+func (idx *refIndex) replace(refs map[string]string) {
+	idx.mu.Lock()
+	idx.refs = refs
+	idx.mu.Unlock()
+}
+
+// buildRefIndex scans every page for reference IDs. A page can declare its own
+// ID with a `ref` frontmatter key, and sections are referenced by explicit
+// heading IDs. Duplicate IDs are logged and the first one found wins.
+func (s *Server) buildRefIndex() error {
+	refs := map[string]string{}
+	add := func(id, url, relPath string) {
+		if existing, ok := refs[id]; ok {
+			slog.Warn("duplicate reference ID", "id", id, "path", relPath, "defined_at", existing)
+			return
+		}
+		refs[id] = url
+	}
+
+	err := s.walkMarkdown(func(path, relPath string, info fs.FileInfo) error {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		meta, body, err := parseFrontmatter(content)
+		if err != nil {
+			slog.Warn("invalid frontmatter", "path", relPath, "err", err)
+		}
+
+		pageURL := s.urlPrefix + s.cleanURL(relPath)
+		if id := metaString(meta, "ref"); id != "" {
+			add(id, pageURL, relPath)
+		}
+
+		inFence := false
+		for _, line := range strings.Split(string(body), "\n") {
+			line = strings.TrimSpace(line)
+			if strings.HasPrefix(line, "```") || strings.HasPrefix(line, "~~~") {
+				inFence = !inFence
+				continue
+			}
+			if inFence {
+				continue
+			}
+			if match := explicitHeadingID.FindStringSubmatch(line); match != nil {
+				add(match[1], pageURL+"#"+match[1], relPath)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	s.refs.replace(refs)
+	return nil
+}
+
+// resolveRefs rewrites ref: link destinations to the URL of their target.
+// Unresolved references are left untouched so the renderer can flag them.
+func (s *Server) resolveRefs(doc ast.Node) {
+	ast.WalkFunc(doc, func(node ast.Node, entering bool) ast.WalkStatus {
+		link, ok := node.(*ast.Link)
+		if !ok || !entering || !bytes.HasPrefix(link.Destination, refPrefix) {
+			return ast.GoToNext
+		}
+		if url, ok := s.refs.lookup(string(link.Destination[len(refPrefix):])); ok {
+			link.Destination = []byte(url)
+		}
+		return ast.GoToNext
+	})
+}
+
+// renderUnresolvedRef renders a link whose reference ID could not be resolved
+// as a visible warning instead of a dead link
+func renderUnresolvedRef(w io.Writer, link *ast.Link, entering bool) {
+	if entering {
+		id := template.HTMLEscapeString(string(link.Destination[len(refPrefix):]))
+		fmt.Fprintf(w, `<span class="unresolved-ref" title="Unresolved reference: %s">&#9888; `, id)
+		return
+	}
+	io.WriteString(w, "</span>")
+}
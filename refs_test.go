@@ -0,0 +1,67 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/gomarkdown/markdown/ast"
+)
+
+// link returns a link node to destination with text as its label
+func link(destination, text string) *ast.Link {
+	l := &ast.Link{Destination: []byte(destination)}
+	ast.AppendChild(l, &ast.Text{Leaf: ast.Leaf{Literal: []byte(text)}})
+	return l
+}
+
+func TestResolveRefs(t *testing.T) {
+	s := newTestServer(t, map[string]string{
+		"index.md":       "# Home\n",
+		"guide/auth.md":  "---\nref: auth-guide\n---\n\n# Auth\n\n## Setup {#setup-auth}\n\n```\n## Example {#not-a-ref}\n```\n",
+		"guide/other.md": "# Other\n\n## Again {#setup-auth}\n",
+	}, func(c *Config) { c.CrossRefs = true })
+	if err := s.buildRefIndex(); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		destination string
+		want        string
+	}{
+		{"page ref", "ref:auth-guide", "/guide/auth"},
+		{"heading ref", "ref:setup-auth", "/guide/auth#setup-auth"},
+		{"ref in code block", "ref:not-a-ref", "ref:not-a-ref"},
+		{"unknown ref", "ref:missing", "ref:missing"},
+		{"ordinary link", "other.md", "other.md"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := link(tt.destination, "see")
+			s.resolveRefs(document(l))
+			if got := string(l.Destination); got != tt.want {
+				t.Errorf("destination = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnresolvedRefIsFlagged(t *testing.T) {
+	s := newTestServer(t, nil, func(c *Config) { c.CrossRefs = true })
+	l := link("ref:<missing>", "see")
+
+	var buf bytes.Buffer
+	for _, entering := range []bool{true, false} {
+		if _, handled := s.renderNodeHook(&buf, l, entering); !handled {
+			t.Fatalf("unresolved ref not handled by the render hook (entering=%v)", entering)
+		}
+	}
+	got := buf.String()
+	if !strings.Contains(got, `class="unresolved-ref"`) || !strings.Contains(got, "&lt;missing&gt;") {
+		t.Errorf("rendered %q, want an escaped unresolved-ref warning", got)
+	}
+	if strings.Contains(got, "href") {
+		t.Errorf("rendered %q, want no link", got)
+	}
+}
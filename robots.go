@@ -0,0 +1,47 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+// robotsFile is served as /robots.txt when it exists in the content directory
+const robotsFile = "robots.txt"
+
+// handleRobots serves robots.txt from the content directory at the site root,
+// or a default that allows every crawler apart from the ROBOTS_DISALLOW paths
+// and, when SITE_BASE_URL is set, points them at the sitemap
+func (s *Server) handleRobots(w http.ResponseWriter, r *http.Request) {
+	root := s
+	if len(s.mounts) > 0 {
+		m, ok := s.matchMount("/" + robotsFile)
+		if ok && m.prefix == "/" {
+			root = m.server
+		} else {
+			root = nil
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Cache-Control", s.config.CacheControl)
+	if root != nil {
+		robotsPath := filepath.Join(root.config.ContentDir, robotsFile)
+		if info, err := os.Stat(robotsPath); err == nil && !info.IsDir() && root.isPathSafe(robotsPath) {
+			http.ServeFile(w, r, robotsPath)
+			return
+		}
+	}
+
+	fmt.Fprint(w, "User-agent: *\n")
+	if len(s.config.RobotsDisallow) == 0 {
+		fmt.Fprint(w, "Allow: /\n")
+	}
+	for _, path := range s.config.RobotsDisallow {
+		fmt.Fprintf(w, "Disallow: %s\n", path)
+	}
+	if s.config.SiteBaseURL != "" {
+		fmt.Fprintf(w, "Sitemap: %s/sitemap.xml\n", s.config.SiteBaseURL)
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+)
+
+// route is a reserved path served by the server itself rather than from the
+// content directory, and the methods it accepts
+type route struct {
+	path    string
+	methods []string
+	handler http.HandlerFunc
+}
+
+// readOnly is the method set of routes that only serve content
+var readOnly = []string{http.MethodGet, http.MethodHead}
+
+// routes lists the reserved paths. They are matched exactly, or as a prefix
+// when they end in a slash, and take precedence over pages with the same
+// name, so new features should add their endpoints here instead of
+// special-casing paths in handleMarkdown.
+func (s *Server) routes() []route {
+	routes := []route{
+		// Health probes skip the security headers; they are never rendered by a browser
+		{healthzPath, readOnly, s.handleHealthz},
+		{readyzPath, readOnly, s.handleReadyz},
+
+		{"/feed.json", readOnly, s.securityHeadersMiddleware(s.handleJSONFeed)},
+		{"/feed.xml", readOnly, s.securityHeadersMiddleware(s.handleXMLFeed)},
+		{"/feed.atom", readOnly, s.securityHeadersMiddleware(s.handleAtomFeed)},
+		{"/api/page", readOnly, s.securityHeadersMiddleware(s.handleAPIPage)},
+		{"/search", readOnly, s.securityHeadersMiddleware(s.handleSearch)},
+		{"/search-index.json", readOnly, s.securityHeadersMiddleware(s.handleSearchIndex)},
+		{"/sitemap.xml", readOnly, s.securityHeadersMiddleware(s.handleSitemap)},
+		{"/robots.txt", readOnly, s.securityHeadersMiddleware(s.handleRobots)},
+		{"/favicon.ico", readOnly, s.securityHeadersMiddleware(s.handleFavicon)},
+		{"/__mermaid.js", readOnly, s.securityHeadersMiddleware(s.handleMermaidScript)},
+		{"/__highlight.css", readOnly, s.securityHeadersMiddleware(s.handleHighlightCSS)},
+		{"/__katex.js", readOnly, s.securityHeadersMiddleware(s.handleKaTeXScript)},
+		{"/__tasks.js", readOnly, s.securityHeadersMiddleware(s.handleTasksScript)},
+		{"/__theme.js", readOnly, s.securityHeadersMiddleware(s.handleThemeScript)},
+	}
+	if s.config.AssetsDir != "" {
+		routes = append(routes, route{assetsPrefix, readOnly, s.securityHeadersMiddleware(s.handleAssets())})
+	}
+	if s.metrics != nil {
+		routes = append(routes, route{metricsPath, readOnly, s.handleMetrics})
+	}
+	if s.liveReload != nil {
+		routes = append(routes,
+			route{"/__livereload", readOnly, s.securityHeadersMiddleware(s.handleLiveReload)},
+			route{"/__livereload.js", readOnly, s.securityHeadersMiddleware(s.handleLiveReloadScript)},
+		)
+	}
+	return routes
+}
+
+// newRouter dispatches the reserved paths and sends every other request to the
+// content catch-all: the mounts when configured, otherwise the content directory
+func (s *Server) newRouter() *http.ServeMux {
+	mux := http.NewServeMux()
+	for _, rt := range s.routes() {
+		mux.HandleFunc(rt.path, allowMethods(rt.methods, rt.handler))
+	}
+
+	content := s.handleMarkdown
+	if len(s.mounts) > 0 {
+		content = s.handleMounts
+	}
+	mux.HandleFunc("/", allowMethods(readOnly, s.securityHeadersMiddleware(content)))
+	return mux
+}
+
+// allowMethods answers requests with methods other than the given ones with
+// 405 Method Not Allowed and an Allow header listing them, so handlers only
+// ever see the methods they support
+func allowMethods(methods []string, next http.HandlerFunc) http.HandlerFunc {
+	allowed := make(map[string]bool, len(methods))
+	for _, method := range methods {
+		allowed[method] = true
+	}
+	allow := strings.Join(methods, ", ")
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !allowed[r.Method] {
+			w.Header().Set("Allow", allow)
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		next(w, r)
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMethodNotAllowed(t *testing.T) {
+	s := newTestServer(t, map[string]string{"index.md": "# Home\n", "page.md": "# Page\n"}, nil)
+	paths := []string{"/", "/page", "/feed.json", "/search", "/sitemap.xml", healthzPath}
+	tests := []struct {
+		method string
+		status int
+	}{
+		{http.MethodGet, http.StatusOK},
+		{http.MethodHead, http.StatusOK},
+		{http.MethodPost, http.StatusMethodNotAllowed},
+		{http.MethodPut, http.StatusMethodNotAllowed},
+		{http.MethodPatch, http.StatusMethodNotAllowed},
+		{http.MethodDelete, http.StatusMethodNotAllowed},
+		{http.MethodOptions, http.StatusMethodNotAllowed},
+	}
+	for _, path := range paths {
+		for _, tt := range tests {
+			t.Run(tt.method+" "+path, func(t *testing.T) {
+				w := serve(s, httptest.NewRequest(tt.method, path, nil))
+				if w.Code != tt.status {
+					t.Errorf("status = %d, want %d", w.Code, tt.status)
+				}
+				allow := w.Header().Get("Allow")
+				if tt.status == http.StatusMethodNotAllowed && allow != "GET, HEAD" {
+					t.Errorf("Allow = %q, want GET, HEAD", allow)
+				}
+				if tt.status == http.StatusOK && allow != "" {
+					t.Errorf("Allow = %q on an allowed method", allow)
+				}
+			})
+		}
+	}
+}
+
+func TestAllowMethods(t *testing.T) {
+	handler := allowMethods([]string{http.MethodPost}, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	tests := []struct {
+		method string
+		status int
+	}{
+		{http.MethodPost, http.StatusNoContent},
+		{http.MethodGet, http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		handler(w, httptest.NewRequest(tt.method, "/", nil))
+		if w.Code != tt.status {
+			t.Errorf("%s: status = %d, want %d", tt.method, w.Code, tt.status)
+		}
+		if tt.status == http.StatusMethodNotAllowed && w.Header().Get("Allow") != http.MethodPost {
+			t.Errorf("%s: Allow = %q, want POST", tt.method, w.Header().Get("Allow"))
+		}
+	}
+}
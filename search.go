@@ -0,0 +1,400 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"html/template"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"unicode"
+)
+
+// maxSearchResults caps the number of results returned for a query
+const maxSearchResults = 50
+
+// What the search index stores (SEARCH_INDEX): every term of each page, or
+// only the terms of its title, for a much smaller index on large sites
+const (
+	searchIndexFull   = "full"
+	searchIndexTitles = "titles"
+)
+
+// searchSnippetRadius is the number of characters shown on each side of the
+// first match in a result snippet
+const searchSnippetRadius = 80
+
+// stopWords are common words left out of the index and ignored in queries
+var stopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true,
+	"be": true, "but": true, "by": true, "for": true, "from": true, "has": true,
+	"have": true, "if": true, "in": true, "into": true, "is": true, "it": true,
+	"its": true, "not": true, "of": true, "on": true, "or": true, "so": true,
+	"that": true, "the": true, "their": true, "then": true, "there": true,
+	"these": true, "this": true, "to": true, "was": true, "were": true,
+	"will": true, "with": true, "you": true, "your": true,
+}
+
+// mdWhitespaces matches the runs of whitespace collapsed in single-line prose
+var mdWhitespaces = regexp.MustCompile(`\s+`)
+
+// stripMarkdown returns the text a reader would see, leaving out code blocks,
+// as a single line of prose
+func stripMarkdown(body []byte) string {
+	return mdWhitespaces.ReplaceAllString(markdownText(body, false), " ")
+}
+
+// searchTerms splits text into lower-case words, dropping stop words
+func searchTerms(text string) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	terms := words[:0]
+	for _, word := range words {
+		if !stopWords[word] {
+			terms = append(terms, word)
+		}
+	}
+	return terms
+}
+
+// searchDoc is an indexed page
+type searchDoc struct {
+	Title string
+	URL   string
+	Text  string
+}
+
+// searchIndex is an in-memory inverted index from terms to the documents
+// containing them and how often they occur
+type searchIndex struct {
+	mu       sync.RWMutex
+	docs     []searchDoc
+	postings map[string]map[int]int
+	// fingerprint identifies the content the index was built from
+	fingerprint string
+
+	// buildMu serializes rebuilds, and pending is set while a rebuild is
+	// waiting to start, so a burst of changes queues at most one more scan
+	buildMu sync.Mutex
+	pending atomic.Bool
+}
+
+func (idx *searchIndex) replace(fingerprint string, docs []searchDoc, postings map[string]map[int]int) {
+	idx.mu.Lock()
+	idx.fingerprint, idx.docs, idx.postings = fingerprint, docs, postings
+	idx.mu.Unlock()
+}
+
+// stale reports whether the index was built from different content than fingerprint
+func (idx *searchIndex) stale(fingerprint string) bool {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return idx.postings != nil && idx.fingerprint != fingerprint
+}
+
+// size returns the approximate number of bytes the index holds: the text of
+// its documents, its terms and an estimate for each posting
+func (idx *searchIndex) size() int {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	n := 0
+	for _, doc := range idx.docs {
+		n += len(doc.Title) + len(doc.URL) + len(doc.Text)
+	}
+	for term, docs := range idx.postings {
+		// a map entry costs roughly a key and a value
+		n += len(term) + 16*len(docs)
+	}
+	return n
+}
+
+// ready reports whether the index has been built at least once
+func (idx *searchIndex) ready() bool {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return idx.postings != nil
+}
+
+// searchResult is a page matching a query
+type searchResult struct {
+	Title   string `json:"title"`
+	URL     string `json:"url"`
+	Snippet string `json:"snippet"`
+	Score   int    `json:"score"`
+}
+
+// search returns the pages containing every term, scored by the total number
+// of times the terms occur in each page
+func (idx *searchIndex) search(terms []string) []searchResult {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	if len(terms) == 0 || idx.postings == nil {
+		return nil
+	}
+
+	scores := map[int]int{}
+	for i, term := range terms {
+		matches := map[int]int{}
+		for doc, count := range idx.postings[term] {
+			if i == 0 {
+				matches[doc] = count
+			} else if score, ok := scores[doc]; ok {
+				matches[doc] = score + count
+			}
+		}
+		scores = matches
+	}
+
+	results := make([]searchResult, 0, len(scores))
+	for doc, score := range scores {
+		results = append(results, searchResult{
+			Title:   idx.docs[doc].Title,
+			URL:     idx.docs[doc].URL,
+			Snippet: searchSnippet(idx.docs[doc].Text, terms),
+			Score:   score,
+		})
+	}
+	return results
+}
+
+// searchSnippet returns the text around the first occurrence of any term
+func searchSnippet(text string, terms []string) string {
+	lower := strings.ToLower(text)
+	pos := -1
+	for _, term := range terms {
+		if i := strings.Index(lower, term); i >= 0 && (pos < 0 || i < pos) {
+			pos = i
+		}
+	}
+	if pos < 0 || pos > len(text) {
+		pos = 0
+	}
+
+	start, end := pos-searchSnippetRadius, pos+searchSnippetRadius
+	prefix, suffix := "…", "…"
+	if start <= 0 {
+		start, prefix = 0, ""
+	} else if space := strings.IndexByte(text[start:pos], ' '); space >= 0 {
+		start += space + 1
+	}
+	if end >= len(text) {
+		end, suffix = len(text), ""
+	} else if space := strings.LastIndexByte(text[pos:end], ' '); space > 0 {
+		end = pos + space
+	}
+	return prefix + strings.ToValidUTF8(text[start:end], "") + suffix
+}
+
+// buildSearchIndex indexes the prose of every page in the content directory,
+// or only the titles with SEARCH_INDEX=titles. Each page contributes at most
+// SEARCH_MAX_TERMS terms, when set.
+func (s *Server) buildSearchIndex() error {
+	fingerprint, err := s.contentFingerprint()
+	if err != nil {
+		return err
+	}
+	var docs []searchDoc
+	postings := map[string]map[int]int{}
+
+	err = s.walkMarkdown(func(path, relPath string, info fs.FileInfo) error {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		meta, body, err := parseFrontmatter(content)
+		if err != nil {
+			slog.Warn("invalid frontmatter", "path", relPath, "err", err)
+		}
+		if !s.isPublic(meta, relPath) {
+			return nil
+		}
+
+		doc := searchDoc{
+			Title: s.pageTitle(meta, body),
+			URL:   s.urlPrefix + s.cleanURL(relPath),
+		}
+		if s.config.SearchIndex != searchIndexTitles {
+			doc.Text = stripMarkdown(body)
+		}
+		id := len(docs)
+		docs = append(docs, doc)
+		terms := searchTerms(doc.Title + " " + doc.Text)
+		if limit := s.config.SearchMaxTerms; limit > 0 && len(terms) > limit {
+			terms = terms[:limit]
+		}
+		for _, term := range terms {
+			if postings[term] == nil {
+				postings[term] = map[int]int{}
+			}
+			postings[term][id]++
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	s.search.replace(fingerprint, docs, postings)
+	return nil
+}
+
+// rebuildSearchIndex rebuilds the search index in the background. Requests keep
+// using the previous index until the new one is ready.
+func (s *Server) rebuildSearchIndex() {
+	if !s.search.pending.CompareAndSwap(false, true) {
+		return // a rebuild is already waiting and will see the latest content
+	}
+	go func() {
+		s.search.buildMu.Lock()
+		defer s.search.buildMu.Unlock()
+		s.search.pending.Store(false)
+		if err := s.buildSearchIndex(); err != nil {
+			slog.Error("failed to build search index", "err", err)
+		}
+	}()
+}
+
+// searchIndexEntry is a page in /search-index.json
+type searchIndexEntry struct {
+	URL   string `json:"url"`
+	Title string `json:"title"`
+	Body  string `json:"body"`
+}
+
+// searchIndexEntries returns the plain text of every published page in the
+// content directory
+func (s *Server) searchIndexEntries() ([]searchIndexEntry, error) {
+	var entries []searchIndexEntry
+	err := s.walkMarkdown(func(path, relPath string, info fs.FileInfo) error {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		meta, body, err := parseFrontmatter(content)
+		if err != nil {
+			slog.Warn("invalid frontmatter", "path", relPath, "err", err)
+		}
+		if !s.isPublic(meta, relPath) {
+			return nil
+		}
+		entries = append(entries, searchIndexEntry{
+			URL:   s.urlPrefix + s.cleanURL(relPath),
+			Title: s.pageTitle(meta, body),
+			Body:  stripMarkdown(body),
+		})
+		return nil
+	})
+	return entries, err
+}
+
+// handleSearchIndex serves /search-index.json, the text of every page for
+// client-side search libraries. It is built on first request and cached until
+// the content changes.
+func (s *Server) handleSearchIndex(w http.ResponseWriter, r *http.Request) {
+	fingerprint, err := s.combinedFingerprint()
+	if err != nil {
+		http.Error(w, "Error reading content", http.StatusInternalServerError)
+		return
+	}
+
+	data, err := s.searchIndexCache.get(fingerprint, func() ([]byte, error) {
+		entries := []searchIndexEntry{}
+		for _, server := range s.contentServers() {
+			serverEntries, err := server.searchIndexEntries()
+			if err != nil {
+				return nil, err
+			}
+			entries = append(entries, serverEntries...)
+		}
+		return json.Marshal(entries)
+	})
+	if err != nil {
+		http.Error(w, "Error building search index", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Cache-Control", s.config.CacheControl)
+	w.Write(data)
+}
+
+// searchTemplate renders the body of the search results page
+var searchTemplate = template.Must(template.New("search").Parse(`<h1>Search</h1>
+<form class="search-form" action="" method="get">
+    <input type="search" name="q" value="{{.Query}}" aria-label="Search">
+    <button type="submit">Search</button>
+</form>
+{{- if .Query}}
+<ul class="search-results">
+{{- range .Results}}
+    <li><a href="{{.URL}}">{{.Title}}</a><p>{{.Snippet}}</p></li>
+{{- else}}
+    <li>No pages found.</li>
+{{- end}}
+</ul>
+{{- end}}`))
+
+// handleSearch serves /search?q=..., as an HTML results page or as JSON when
+// the client asks for it. Results from every content directory are merged and
+// sorted by score.
+func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	terms := searchTerms(query)
+
+	// Without a file watcher, changed content is noticed here. This query is
+	// answered from the current index while the new one is built.
+	var results []searchResult
+	for _, server := range s.contentServers() {
+		if fingerprint, err := server.contentFingerprint(); err == nil && server.search.stale(fingerprint) {
+			server.rebuildSearchIndex()
+		}
+		results = append(results, server.search.search(terms)...)
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].URL < results[j].URL
+	})
+	if len(results) > maxSearchResults {
+		results = results[:maxSearchResults]
+	}
+
+	w.Header().Add("Vary", "Accept")
+	if wantsJSON(r) {
+		if results == nil {
+			results = []searchResult{}
+		}
+		data, err := json.Marshal(struct {
+			Query   string         `json:"query"`
+			Results []searchResult `json:"results"`
+		}{query, results})
+		if err != nil {
+			http.Error(w, "Error encoding results", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.Write(data)
+		return
+	}
+
+	var body bytes.Buffer
+	if err := searchTemplate.Execute(&body, struct {
+		Query   string
+		Results []searchResult
+	}{query, results}); err != nil {
+		http.Error(w, "Template error", http.StatusInternalServerError)
+		return
+	}
+	s.renderPage(w, r, http.StatusOK, pageData{
+		Title:   "Search",
+		Content: template.HTML(body.String()),
+	})
+}
@@ -0,0 +1,276 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// searchFixtures are pages with known words in their titles and bodies
+var searchFixtures = map[string]string{
+	"index.md":         "# Home\n\nWelcome to the documentation.\n",
+	"install.md":       "---\ntitle: Installing Kubernetes\n---\n\nDownload the binary and run the installer.\n",
+	"guide/upgrade.md": "---\ntitle: Upgrade Guide\n---\n\nBefore you upgrade Kubernetes, back up the cluster.\n",
+	"draft.md":         "---\ntitle: Kubernetes Secrets\ndraft: true\n---\n",
+}
+
+// searchJSON queries /search as JSON and returns the result URLs
+func searchJSON(t *testing.T, s *Server, query string) []string {
+	t.Helper()
+	r := httptest.NewRequest(http.MethodGet, "/search?q="+query, nil)
+	r.Header.Set("Accept", "application/json")
+	w := serve(s, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", w.Code)
+	}
+	var response struct {
+		Query   string         `json:"query"`
+		Results []searchResult `json:"results"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	urls := []string{}
+	for _, result := range response.Results {
+		urls = append(urls, result.URL)
+	}
+	return urls
+}
+
+func TestSearchTerms(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"Install the Server", []string{"install", "server"}},
+		{"HTTP/3 and TLS-1.3", []string{"http", "3", "tls", "1", "3"}},
+		{"Über die Größe", []string{"über", "die", "größe"}},
+		{"the and of", []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			if got := searchTerms(tt.text); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("searchTerms(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSearch(t *testing.T) {
+	s := newTestServer(t, searchFixtures, nil)
+	if err := s.buildSearchIndex(); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		query string
+		want  []string
+	}{
+		{"title term", "kubernetes", []string{"/guide/upgrade", "/install"}},
+		{"case insensitive", "KUBERNETES", []string{"/guide/upgrade", "/install"}},
+		{"body term", "cluster", []string{"/guide/upgrade"}},
+		{"all terms must match", "kubernetes+binary", []string{"/install"}},
+		{"no match", "kubernetes+windows", []string{}},
+		{"only stop words", "the", []string{}},
+		{"empty", "", []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := searchJSON(t, s, tt.query); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("results = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSearchScoresByOccurrences(t *testing.T) {
+	s := newTestServer(t, map[string]string{
+		"once.md":  "---\ntitle: Once\n---\n\nA cache.\n",
+		"often.md": "---\ntitle: Often\n---\n\nThe cache, the cache and the cache.\n",
+	}, nil)
+	if err := s.buildSearchIndex(); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"/often", "/once"}
+	if got := searchJSON(t, s, "cache"); !reflect.DeepEqual(got, want) {
+		t.Errorf("results = %q, want %q", got, want)
+	}
+}
+
+func TestSearchHTML(t *testing.T) {
+	s := newTestServer(t, searchFixtures, nil)
+	if err := s.buildSearchIndex(); err != nil {
+		t.Fatal(err)
+	}
+	w := get(s, "/search?q=cluster")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", w.Code)
+	}
+	body := w.Body.String()
+	if !strings.Contains(body, `<a href="/guide/upgrade">Upgrade Guide</a>`) {
+		t.Errorf("results page has no link to the match:\n%s", body)
+	}
+	if !strings.Contains(body, "back up the cluster") {
+		t.Errorf("results page has no snippet:\n%s", body)
+	}
+}
+
+func TestSearchSnippet(t *testing.T) {
+	long := strings.Repeat("lorem ipsum ", 20) + "needle " + strings.Repeat("dolor sit ", 20)
+	tests := []struct {
+		name  string
+		text  string
+		terms []string
+		want  string
+	}{
+		{"short text", "find the needle here", []string{"needle"}, "find the needle here"},
+		{"no match", "nothing here", []string{"needle"}, "nothing here"},
+		{"case insensitive", "A Needle", []string{"needle"}, "A Needle"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := searchSnippet(tt.text, tt.terms); got != tt.want {
+				t.Errorf("searchSnippet = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("long text", func(t *testing.T) {
+		got := searchSnippet(long, []string{"needle"})
+		if !strings.HasPrefix(got, "…") || !strings.HasSuffix(got, "…") || !strings.Contains(got, "needle") {
+			t.Errorf("searchSnippet = %q, want an excerpt around the match", got)
+		}
+		if len(got) > 2*searchSnippetRadius+len("……") {
+			t.Errorf("snippet is %d bytes, want at most %d", len(got), 2*searchSnippetRadius)
+		}
+	})
+}
+
+// searchIndexFixtures are pages with known words in their titles and bodies
+var searchIndexFixtures = map[string]string{
+	"index.md":         "# Home\n\nWelcome to the documentation.\n",
+	"guide/upgrade.md": "---\ntitle: Upgrade Guide\n---\n\nBefore you upgrade Kubernetes, back up the cluster.\n",
+}
+
+// indexedServer returns a server for the search index fixtures with its
+// search index built
+func indexedServer(t *testing.T, configure func(*Config)) *Server {
+	t.Helper()
+	s := newTestServer(t, searchIndexFixtures, configure)
+	if err := s.buildSearchIndex(); err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestSearchIndexModes(t *testing.T) {
+	tests := []struct {
+		name      string
+		configure func(*Config)
+		query     string
+		want      []string
+	}{
+		{"full matches title", nil, "upgrade", []string{"/guide/upgrade"}},
+		{"full matches body", nil, "cluster", []string{"/guide/upgrade"}},
+		{"titles matches title", func(c *Config) { c.SearchIndex = searchIndexTitles }, "upgrade", []string{"/guide/upgrade"}},
+		{"titles ignores body", func(c *Config) { c.SearchIndex = searchIndexTitles }, "cluster", []string{}},
+		{"max terms keeps title", func(c *Config) { c.SearchMaxTerms = 2 }, "upgrade guide", []string{"/guide/upgrade"}},
+		{"max terms drops the rest", func(c *Config) { c.SearchMaxTerms = 2 }, "cluster", []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := indexedServer(t, tt.configure)
+			urls := []string{}
+			for _, result := range s.search.search(searchTerms(tt.query)) {
+				urls = append(urls, result.URL)
+			}
+			sort.Strings(urls)
+			if !reflect.DeepEqual(urls, tt.want) {
+				t.Errorf("results = %q, want %q", urls, tt.want)
+			}
+		})
+	}
+}
+
+func TestSearchIndexSize(t *testing.T) {
+	full := indexedServer(t, nil).search.size()
+	titles := indexedServer(t, func(c *Config) { c.SearchIndex = searchIndexTitles }).search.size()
+	capped := indexedServer(t, func(c *Config) { c.SearchMaxTerms = 1 }).search.size()
+	if full == 0 || titles >= full || capped >= full {
+		t.Errorf("sizes: full %d, titles %d, capped %d; want the limited indexes smaller", full, titles, capped)
+	}
+	if empty := (&searchIndex{}).size(); empty != 0 {
+		t.Errorf("empty index size = %d, want 0", empty)
+	}
+}
+
+func TestSearchIndexConfig(t *testing.T) {
+	tests := []struct {
+		name      string
+		configure func(*Config)
+		valid     bool
+	}{
+		{"default", func(c *Config) {}, true},
+		{"titles", func(c *Config) { c.SearchIndex = searchIndexTitles }, true},
+		{"unknown mode", func(c *Config) { c.SearchIndex = "headings" }, false},
+		{"max terms", func(c *Config) { c.SearchMaxTerms = 500 }, true},
+		{"negative max terms", func(c *Config) { c.SearchMaxTerms = -1 }, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := defaultConfig()
+			tt.configure(&config)
+			if err := config.validate(); (err == nil) != tt.valid {
+				t.Errorf("validate() = %v, want valid %v", err, tt.valid)
+			}
+		})
+	}
+}
+
+func TestSearchIndexJSON(t *testing.T) {
+	s := newTestServer(t, searchFixtures, nil)
+	decode := func() map[string]searchIndexEntry {
+		w := get(s, "/search-index.json")
+		if w.Code != http.StatusOK {
+			t.Fatalf("status = %d, want 200", w.Code)
+		}
+		if got := w.Header().Get("Content-Type"); got != "application/json; charset=utf-8" {
+			t.Errorf("Content-Type = %q", got)
+		}
+		var entries []searchIndexEntry
+		if err := json.Unmarshal(w.Body.Bytes(), &entries); err != nil {
+			t.Fatalf("invalid JSON: %v", err)
+		}
+		byURL := map[string]searchIndexEntry{}
+		for _, entry := range entries {
+			byURL[entry.URL] = entry
+		}
+		return byURL
+	}
+
+	entries := decode()
+	upgrade, ok := entries["/guide/upgrade"]
+	if !ok {
+		t.Fatalf("no entry for guide/upgrade.md in %v", entries)
+	}
+	if upgrade.Title != "Upgrade Guide" {
+		t.Errorf("title = %q, want Upgrade Guide", upgrade.Title)
+	}
+	if !strings.Contains(upgrade.Body, "back up the cluster") || strings.Contains(upgrade.Body, "title:") {
+		t.Errorf("body = %q, want the page text without frontmatter", upgrade.Body)
+	}
+	if _, ok := entries["/draft"]; ok {
+		t.Error("draft page is in the search index")
+	}
+
+	// The cached index is rebuilt when a page is added
+	writeFiles(t, s.config.ContentDir, map[string]string{"faq.md": "---\ntitle: FAQ\n---\n"})
+	if _, ok := decode()["/faq"]; !ok {
+		t.Error("added page missing from the search index")
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+)
+
+// defaultShutdownTimeout is how long, in seconds, requests in progress may
+// take to finish once the server is asked to stop
+const defaultShutdownTimeout = 15
+
+// listener is one of the network servers Run starts, such as the HTTPS server
+// and the plain HTTP redirect next to it
+type listener struct {
+	serve    func() error
+	shutdown func(ctx context.Context) error
+}
+
+// httpListener returns a listener for server, started with serve, one of its
+// ListenAndServe methods
+func httpListener(server *http.Server, serve func() error) listener {
+	return listener{serve: serve, shutdown: server.Shutdown}
+}
+
+// close stops the server's background work
+func (s *Server) close() {
+	s.closeOnce.Do(func() { close(s.done) })
+}
+
+// runListeners serves until ctx is cancelled or one of the listeners fails,
+// then shuts them all down. Shutdown stops accepting connections and waits up
+// to SHUTDOWN_TIMEOUT for requests in progress, so a rolling deployment doesn't
+// drop them. It returns the error that stopped the server, or nil after a
+// requested shutdown.
+func (s *Server) runListeners(ctx context.Context, listeners []listener) error {
+	errs := make(chan error, len(listeners))
+	for _, l := range listeners {
+		go func(serve func() error) {
+			errs <- serve()
+		}(l.serve)
+	}
+
+	var err error
+	select {
+	case err = <-errs:
+	case <-ctx.Done():
+		slog.Info("shutting down", "timeout", seconds(s.config.ShutdownTimeout))
+	}
+
+	// Live reload streams never finish on their own
+	if s.liveReload != nil {
+		s.liveReload.close()
+	}
+	s.close()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), seconds(s.config.ShutdownTimeout))
+	defer cancel()
+	for _, l := range listeners {
+		if shutdownErr := l.shutdown(shutdownCtx); shutdownErr != nil && err == nil {
+			err = shutdownErr
+		}
+	}
+	if err == nil {
+		slog.Info("shutdown complete")
+	}
+	return err
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeListener returns a listener whose serve blocks until it is shut down,
+// or returns serveErr straight away when set
+func fakeListener(serveErr, shutdownErr error, shutdowns *atomic.Int32) listener {
+	stopped := make(chan struct{})
+	return listener{
+		serve: func() error {
+			if serveErr != nil {
+				return serveErr
+			}
+			<-stopped
+			return http.ErrServerClosed
+		},
+		shutdown: func(ctx context.Context) error {
+			shutdowns.Add(1)
+			select {
+			case <-stopped:
+			default:
+				close(stopped)
+			}
+			return shutdownErr
+		},
+	}
+}
+
+func TestRunListeners(t *testing.T) {
+	serveErr := errors.New("address in use")
+	shutdownErr := errors.New("shutdown timed out")
+	tests := []struct {
+		name        string
+		serveErr    error
+		shutdownErr error
+		cancel      bool
+		want        error
+	}{
+		{"cancelled", nil, nil, true, nil},
+		{"listener fails", serveErr, nil, false, serveErr},
+		{"shutdown fails", nil, shutdownErr, true, shutdownErr},
+		{"listener error wins", serveErr, shutdownErr, false, serveErr},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Server{config: defaultConfig(), done: make(chan struct{})}
+			var shutdowns atomic.Int32
+			listeners := []listener{
+				fakeListener(tt.serveErr, tt.shutdownErr, &shutdowns),
+				fakeListener(nil, nil, &shutdowns),
+			}
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tt.cancel {
+				cancel()
+			}
+
+			if err := s.runListeners(ctx, listeners); err != tt.want {
+				t.Errorf("runListeners = %v, want %v", err, tt.want)
+			}
+			if n := shutdowns.Load(); n != int32(len(listeners)) {
+				t.Errorf("%d listeners shut down, want %d", n, len(listeners))
+			}
+			select {
+			case <-s.done:
+			default:
+				t.Error("background work not stopped")
+			}
+		})
+	}
+}
+
+func TestShutdownFinishesRequests(t *testing.T) {
+	started := make(chan struct{})
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		time.Sleep(100 * time.Millisecond)
+		io.WriteString(w, "done")
+	})}
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := &Server{config: defaultConfig(), done: make(chan struct{})}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- s.runListeners(ctx, []listener{httpListener(server, func() error { return server.Serve(l) })})
+	}()
+
+	body := make(chan string, 1)
+	go func() {
+		resp, err := http.Get("http://" + l.Addr().String() + "/")
+		if err != nil {
+			body <- err.Error()
+			return
+		}
+		defer resp.Body.Close()
+		b, _ := io.ReadAll(resp.Body)
+		body <- string(b)
+	}()
+
+	<-started
+	cancel()
+	if got := <-body; got != "done" {
+		t.Errorf("in-flight request got %q, want it to finish", got)
+	}
+	if err := <-done; err != nil {
+		t.Errorf("runListeners = %v, want nil", err)
+	}
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"log/slog"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// navNode is a page or directory in the sidebar navigation tree
+type navNode struct {
+	Title    string
+	URL      string // empty for directories without an index page or listing
+	Active   bool
+	Children []navNode
+}
+
+// navTreeCache holds the navigation tree until the content it was built from changes
+type navTreeCache struct {
+	mu          sync.Mutex
+	fingerprint string
+	tree        []navNode
+}
+
+// navTree returns the sidebar tree for a request, with the current page marked
+// active. The tree is cached and rebuilt when markdown files change.
+func (s *Server) navTree(r *http.Request) []navNode {
+	fingerprint, err := s.contentFingerprint()
+	if err != nil {
+		slog.Warn("failed to scan content for navigation", "err", err)
+		return nil
+	}
+
+	s.navTreeCache.mu.Lock()
+	if s.navTreeCache.tree == nil || s.navTreeCache.fingerprint != fingerprint {
+		tree, err := s.buildNavTree(s.config.ContentDir, "")
+		if err != nil {
+			slog.Warn("failed to build navigation", "err", err)
+		}
+		s.navTreeCache.fingerprint, s.navTreeCache.tree = fingerprint, tree
+	}
+	tree := s.navTreeCache.tree
+	s.navTreeCache.mu.Unlock()
+
+	return markActive(tree, normalizeNavPath(s.urlPrefix+r.URL.Path))
+}
+
+// buildNavTree lists the pages and subdirectories of dir, relDir being its
+// path relative to the content directory with a trailing slash. The default
+// document is folded into its directory's entry, and hidden files, the error
+// pages and directories without any pages are left out.
+func (s *Server) buildNavTree(dir, relDir string) ([]navNode, error) {
+	entries, err := s.readContentDir(relDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var nodes []navNode
+	for _, entry := range entries {
+		name := entry.Name()
+		if strings.HasPrefix(name, ".") {
+			continue
+		}
+		path := filepath.Join(dir, name)
+
+		if entry.IsDir() {
+			if !s.isPublic(nil, relDir+name) {
+				continue
+			}
+			children, err := s.buildNavTree(path, relDir+name+"/")
+			if err != nil {
+				return nil, err
+			}
+			node := navNode{Title: s.titleFromFilename(name), Children: children}
+			indexPath := filepath.Join(path, s.indexFile)
+			_, hasIndex := s.treeFile(relDir + name + "/" + s.indexFile)
+			if hasIndex {
+				node.Title = s.fileTitle(indexPath)
+			}
+			if hasIndex || (len(children) > 0 && s.config.DirectoryListing) {
+				node.URL = s.urlPrefix + "/" + relDir + name + "/"
+			}
+			if hasIndex || len(children) > 0 {
+				nodes = append(nodes, node)
+			}
+			continue
+		}
+
+		if filepath.Ext(name) != ".md" || name == s.indexFile || name == "404.md" || name == "500.md" || !s.isPublicFile(relDir+name) {
+			continue
+		}
+		nodes = append(nodes, navNode{
+			Title: s.fileTitle(path),
+			URL:   s.urlPrefix + s.cleanURL(relDir+name),
+		})
+	}
+	return nodes, nil
+}
+
+// fileTitle returns a page's frontmatter title or first H1, as read into the
+// content tree, falling back to a title derived from its filename
+func (s *Server) fileTitle(path string) string {
+	if relPath := s.contentRelPath(path); relPath != "" {
+		if file, ok := s.treeFile(relPath); ok && file.page.title != "" {
+			return file.page.title
+		}
+	}
+	return s.titleFromFilename(filepath.Base(path))
+}
+
+// markActive returns a copy of nodes with the node for the current path marked active
+func markActive(nodes []navNode, current string) []navNode {
+	if nodes == nil {
+		return nil
+	}
+	marked := make([]navNode, len(nodes))
+	for i, node := range nodes {
+		node.Active = node.URL != "" && normalizeNavPath(node.URL) == current
+		node.Children = markActive(node.Children, current)
+		marked[i] = node
+	}
+	return marked
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"bufio"
+	"encoding/xml"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// sitemapURL is a <url> entry in a sitemap (https://www.sitemaps.org/protocol.html)
+type sitemapURL struct {
+	XMLName    xml.Name `xml:"url"`
+	Loc        string   `xml:"loc"`
+	LastMod    string   `xml:"lastmod"`
+	ChangeFreq string   `xml:"changefreq,omitempty"`
+}
+
+// sitemapChangeFreqs are the values allowed for <changefreq>
+var sitemapChangeFreqs = map[string]bool{
+	"always": true, "hourly": true, "daily": true, "weekly": true,
+	"monthly": true, "yearly": true, "never": true,
+}
+
+// handleSitemap serves /sitemap.xml listing every published page with its
+// modification time and, when its frontmatter sets one, its changefreq.
+// Entries are written while the content directories are walked, one file at a
+// time, so the sitemap is never held in memory. Locations must be absolute.
+func (s *Server) handleSitemap(w http.ResponseWriter, r *http.Request) {
+	baseURL := s.requestBaseURL(r)
+
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	w.Header().Set("Cache-Control", s.config.CacheControl)
+	if r.Method == http.MethodHead {
+		return
+	}
+
+	out := bufio.NewWriter(w)
+	defer out.Flush()
+	out.WriteString(xml.Header)
+	out.WriteString(`<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">` + "\n")
+
+	enc := xml.NewEncoder(out)
+	enc.Indent("  ", "  ")
+	for _, server := range s.contentServers() {
+		err := server.walkMarkdown(func(path, relPath string, info fs.FileInfo) error {
+			if relPath == "404.md" || relPath == "500.md" || !server.isPathSafe(path) {
+				return nil
+			}
+			content, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			meta, _, err := parseFrontmatter(content)
+			if err != nil {
+				slog.Warn("invalid frontmatter", "path", relPath, "err", err)
+			}
+			if !server.isPublic(meta, relPath) {
+				return nil
+			}
+			changeFreq := strings.ToLower(metaString(meta, "changefreq"))
+			if !sitemapChangeFreqs[changeFreq] {
+				changeFreq = ""
+			}
+			return enc.Encode(sitemapURL{
+				Loc:        baseURL + server.urlPrefix + server.cleanURL(relPath),
+				LastMod:    info.ModTime().UTC().Format(time.RFC3339),
+				ChangeFreq: changeFreq,
+			})
+		})
+		if err != nil {
+			// Headers are already sent, so the sitemap is cut short
+			slog.Warn("sitemap incomplete", "err", err)
+			return
+		}
+	}
+	enc.Flush()
+	out.WriteString("\n</urlset>\n")
+}
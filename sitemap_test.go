@@ -0,0 +1,73 @@
+package main
+
+import (
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSitemap(t *testing.T) {
+	s := newTestServer(t, map[string]string{
+		"index.md":         "# Home\n",
+		"guide/setup.md":   "---\nchangefreq: Weekly\n---\n",
+		"guide/index.md":   "---\nchangefreq: sometimes\n---\n",
+		"draft.md":         "---\ndraft: true\n---\n",
+		"_partials/tip.md": "Tip\n",
+		".hidden.md":       "Hidden\n",
+		"404.md":           "# Not found\n",
+		"notes.txt":        "Not a page\n",
+	}, func(c *Config) { c.SiteBaseURL = "https://example.com" })
+
+	modTime := time.Date(2024, 3, 5, 14, 30, 0, 0, time.FixedZone("CET", 3600))
+	for _, name := range []string{"index.md", "guide/setup.md", "guide/index.md"} {
+		if err := os.Chtimes(filepath.Join(s.config.ContentDir, name), modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	w := get(s, "/sitemap.xml")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", w.Code)
+	}
+	if got := w.Header().Get("Content-Type"); got != "application/xml; charset=utf-8" {
+		t.Errorf("Content-Type = %q", got)
+	}
+
+	var sitemap struct {
+		XMLName xml.Name `xml:"http://www.sitemaps.org/schemas/sitemap/0.9 urlset"`
+		URLs    []struct {
+			Loc        string `xml:"loc"`
+			LastMod    string `xml:"lastmod"`
+			ChangeFreq string `xml:"changefreq"`
+		} `xml:"url"`
+	}
+	if err := xml.Unmarshal(w.Body.Bytes(), &sitemap); err != nil {
+		t.Fatalf("invalid sitemap: %v\n%s", err, w.Body.String())
+	}
+
+	got := map[string][2]string{}
+	for _, url := range sitemap.URLs {
+		got[url.Loc] = [2]string{url.LastMod, url.ChangeFreq}
+	}
+	want := map[string][2]string{
+		"https://example.com/":            {"2024-03-05T13:30:00Z", ""},
+		"https://example.com/guide/":      {"2024-03-05T13:30:00Z", ""},
+		"https://example.com/guide/setup": {"2024-03-05T13:30:00Z", "weekly"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("sitemap entries = %v, want %v", got, want)
+	}
+}
+
+func TestSitemapHead(t *testing.T) {
+	s := newTestServer(t, map[string]string{"index.md": "# Home\n"}, nil)
+	w := serve(s, httptest.NewRequest(http.MethodHead, "/sitemap.xml", nil))
+	if w.Code != http.StatusOK || w.Body.Len() != 0 {
+		t.Errorf("HEAD = %d with %d bytes, want 200 and no body", w.Code, w.Body.Len())
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"encoding/json"
+	"html/template"
+	"time"
+)
+
+// jsonLDPerson is a schema.org Person
+type jsonLDPerson struct {
+	Type string `json:"@type"`
+	Name string `json:"name"`
+}
+
+// jsonLDPage is a schema.org Article or WebPage (https://schema.org/Article)
+type jsonLDPage struct {
+	Context       string        `json:"@context"`
+	Type          string        `json:"@type"`
+	Headline      string        `json:"headline"`
+	Description   string        `json:"description,omitempty"`
+	URL           string        `json:"url,omitempty"`
+	Image         string        `json:"image,omitempty"`
+	DatePublished string        `json:"datePublished,omitempty"`
+	DateModified  string        `json:"dateModified"`
+	Author        *jsonLDPerson `json:"author,omitempty"`
+}
+
+// structuredData returns schema.org JSON-LD describing a page: an Article for
+// pages with a frontmatter date, otherwise a WebPage. json.Marshal escapes <,
+// > and &, so the result is safe to embed in a script element.
+func structuredData(data pageData, modTime time.Time) template.JS {
+	page := jsonLDPage{
+		Context:      "https://schema.org",
+		Type:         "WebPage",
+		Headline:     data.Title,
+		Description:  data.Description,
+		URL:          data.URL,
+		Image:        data.Image,
+		DateModified: modTime.UTC().Format(time.RFC3339),
+	}
+	if date, ok := metaDate(data.Meta, "date"); ok {
+		page.Type = "Article"
+		page.DatePublished = date.Format(time.RFC3339)
+	}
+	if author := metaString(data.Meta, "author"); author != "" {
+		page.Author = &jsonLDPerson{Type: "Person", Name: author}
+	}
+
+	out, err := json.Marshal(page)
+	if err != nil {
+		return ""
+	}
+	return template.JS(out)
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+// jsonLDScript matches the JSON-LD script element in a rendered page
+var jsonLDScript = regexp.MustCompile(`<script type="application/ld\+json">(.*?)</script>`)
+
+func TestStructuredData(t *testing.T) {
+	files := map[string]string{
+		"post.md":  "---\ntitle: Release Notes\ndate: 2024-01-15\nauthor: Jane Doe\ndescription: What changed\n---\n",
+		"about.md": "---\ntitle: About </script> Us\n---\n",
+	}
+	modTime := time.Date(2024, 2, 1, 8, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		path string
+		want map[string]interface{}
+	}{
+		{"/post", map[string]interface{}{
+			"@context":      "https://schema.org",
+			"@type":         "Article",
+			"headline":      "Release Notes",
+			"description":   "What changed",
+			"datePublished": "2024-01-15T00:00:00Z",
+			"dateModified":  "2024-02-01T08:00:00Z",
+			"author":        map[string]interface{}{"@type": "Person", "name": "Jane Doe"},
+		}},
+		// The headline can't close the script element early
+		{"/about", map[string]interface{}{
+			"@type":    "WebPage",
+			"headline": "About </script> Us",
+		}},
+	}
+	s := newTestServer(t, files, func(c *Config) { c.StructuredData = true })
+	for name := range files {
+		if err := os.Chtimes(filepath.Join(s.config.ContentDir, name), modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			body := get(s, tt.path).Body.String()
+			match := jsonLDScript.FindStringSubmatch(body)
+			if match == nil {
+				t.Fatalf("no JSON-LD in page:\n%s", body)
+			}
+			var data map[string]interface{}
+			if err := json.Unmarshal([]byte(match[1]), &data); err != nil {
+				t.Fatalf("invalid JSON-LD %q: %v", match[1], err)
+			}
+			for key, want := range tt.want {
+				got, _ := json.Marshal(data[key])
+				wantJSON, _ := json.Marshal(want)
+				if string(got) != string(wantJSON) {
+					t.Errorf("%s = %s, want %s", key, got, wantJSON)
+				}
+			}
+		})
+	}
+}
+
+func TestStructuredDataDisabled(t *testing.T) {
+	s := newTestServer(t, map[string]string{"post.md": "---\ntitle: Post\n---\n"}, nil)
+	if body := get(s, "/post").Body.String(); strings.Contains(body, "application/ld+json") {
+		t.Error("JSON-LD emitted without STRUCTURED_DATA")
+	}
+}
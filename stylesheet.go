@@ -0,0 +1,54 @@
+package main
+
+import (
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+)
+
+// styleSheetName is the stylesheet looked up in each content directory
+const styleSheetName = "style.css"
+
+// nearestStyleSheet returns the path, relative to the content directory, of the
+// style.css closest to relDir: the one in relDir itself, else the nearest
+// parent's, stopping at the content root. It reports false if there is none.
+func (s *Server) nearestStyleSheet(relDir string) (string, bool) {
+	for dir := path.Clean(relDir); ; dir = path.Dir(dir) {
+		relPath := path.Join(dir, styleSheetName)
+		cssPath := filepath.Join(s.config.ContentDir, filepath.FromSlash(relPath))
+		// Security: Ensure the resolved path is still within content directory
+		if s.isPathSafe(cssPath) {
+			if info, err := os.Stat(cssPath); err == nil && !info.IsDir() {
+				return relPath, true
+			}
+		}
+		if dir == "." || dir == "/" {
+			return "", false
+		}
+	}
+}
+
+// styleSheetURL returns the URL of the stylesheet for pages in relDir
+func (s *Server) styleSheetURL(relDir string) string {
+	if relPath, ok := s.nearestStyleSheet(relDir); ok {
+		return s.urlPrefix + "/" + relPath
+	}
+	return s.urlPrefix + "/" + styleSheetName
+}
+
+// serveStyleSheet serves the style.css nearest to the requested one, so a
+// section without its own stylesheet uses its parent's
+func (s *Server) serveStyleSheet(w http.ResponseWriter, r *http.Request, urlPath string) {
+	relPath, ok := s.nearestStyleSheet(path.Dir(urlPath))
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "text/css; charset=utf-8")
+	w.Header().Set("Cache-Control", s.config.CacheControl)
+	if servePrecompressed(w, r, s.openContent, relPath) {
+		return
+	}
+	http.ServeFile(w, r, filepath.Join(s.config.ContentDir, filepath.FromSlash(relPath)))
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/gomarkdown/markdown/ast"
+)
+
+// taskMarkers are the markers that start a task list item, and whether each
+// marks the task as done
+var taskMarkers = []struct {
+	marker []byte
+	done   bool
+}{
+	{[]byte("[ ]"), false},
+	{[]byte("[x]"), true},
+	{[]byte("[X]"), true},
+}
+
+// Checkboxes replacing task markers, disabled unless the page opts in to
+// interactive task lists
+const (
+	taskCheckbox     = `<input type="checkbox" class="task-list-checkbox" disabled> `
+	taskCheckboxDone = `<input type="checkbox" class="task-list-checkbox" disabled checked> `
+)
+
+// markTaskListItems replaces the "[ ]" or "[x]" at the start of list items
+// with checkboxes, GitHub style. It reports whether the document has any.
+func markTaskListItems(doc ast.Node) bool {
+	found := false
+	ast.WalkFunc(doc, func(node ast.Node, entering bool) ast.WalkStatus {
+		item, ok := node.(*ast.ListItem)
+		if !ok || !entering || len(item.Children) == 0 {
+			return ast.GoToNext
+		}
+		para, ok := item.Children[0].(*ast.Paragraph)
+		if !ok || len(para.Children) == 0 {
+			return ast.GoToNext
+		}
+		text, ok := para.Children[0].(*ast.Text)
+		if !ok {
+			return ast.GoToNext
+		}
+
+		for _, task := range taskMarkers {
+			rest, ok := bytes.CutPrefix(text.Literal, task.marker)
+			if !ok || (len(rest) > 0 && rest[0] != ' ') {
+				continue
+			}
+			checkbox := taskCheckbox
+			if task.done {
+				checkbox = taskCheckboxDone
+			}
+			text.Literal = bytes.TrimPrefix(rest, []byte(" "))
+			span := &ast.HTMLSpan{Leaf: ast.Leaf{Literal: []byte(checkbox)}}
+			span.SetParent(para)
+			para.Children = append([]ast.Node{span}, para.Children...)
+			found = true
+			break
+		}
+		return ast.GoToNext
+	})
+	return found
+}
+
+// isTaskListItem reports whether node is a list item starting with a checkbox
+func isTaskListItem(node ast.Node) bool {
+	item, ok := node.(*ast.ListItem)
+	if !ok || len(item.Children) == 0 {
+		return false
+	}
+	para, ok := item.Children[0].(*ast.Paragraph)
+	if !ok || len(para.Children) == 0 {
+		return false
+	}
+	span, ok := para.Children[0].(*ast.HTMLSpan)
+	return ok && bytes.HasPrefix(span.Literal, []byte(`<input type="checkbox" class="task-list-checkbox"`))
+}
+
+// renderTaskListItem writes the <li> tags of a task list item with a class
+// used to drop its bullet
+func renderTaskListItem(w io.Writer, entering bool) {
+	if entering {
+		io.WriteString(w, `<li class="task-list-item">`)
+	} else {
+		io.WriteString(w, "</li>\n")
+	}
+}
+
+// tasksScript makes the checkboxes on a page clickable and remembers their
+// state in the browser's localStorage, keyed by page and checkbox position
+const tasksScript = `(function() {
+    var key = 'tasks:' + location.pathname;
+    var saved = {};
+    try { saved = JSON.parse(localStorage.getItem(key)) || {}; } catch (e) {}
+    document.querySelectorAll('.task-list-checkbox').forEach(function(box, i) {
+        box.disabled = false;
+        if (i in saved) box.checked = saved[i];
+        box.addEventListener('change', function() {
+            saved[i] = box.checked;
+            localStorage.setItem(key, JSON.stringify(saved));
+        });
+    });
+})();
+`
+
+func (s *Server) handleTasksScript(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/javascript")
+	w.Header().Set("Cache-Control", s.config.CacheControl)
+	fmt.Fprint(w, tasksScript)
+}
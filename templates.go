@@ -0,0 +1,149 @@
+package main
+
+import (
+	"fmt"
+	"html/template"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// templateFuncs are the helper functions available to page templates
+var templateFuncs = template.FuncMap{
+	"now":   time.Now,
+	"year":  func() int { return time.Now().Year() },
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+	// default returns value, or fallback if value is empty, e.g. {{default "Untitled" .Title}}
+	"default": func(fallback, value interface{}) interface{} {
+		if value == nil {
+			return fallback
+		}
+		if v := reflect.ValueOf(value); v.IsZero() {
+			return fallback
+		}
+		return value
+	},
+}
+
+// templateStore holds the page template loaded from TEMPLATE_FILE so it can be
+// swapped safely while requests are being served, and the parsed built-in
+// template used when no file is configured
+type templateStore struct {
+	mu      sync.RWMutex
+	path    string
+	tmpl    *template.Template
+	builtin *template.Template
+	// loadErr is the error of the last load, nil once a load succeeds
+	loadErr error
+}
+
+// newTemplateStore parses the built-in page template and, if path is set, the
+// external one, so template errors surface at startup rather than per request
+func newTemplateStore(path string) (*templateStore, error) {
+	builtin, err := template.New("page").Funcs(templateFuncs).Parse(pageTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse built-in template: %w", err)
+	}
+	ts := &templateStore{path: path, builtin: builtin}
+	if path != "" {
+		if err := ts.load(); err != nil {
+			return nil, err
+		}
+	}
+	return ts, nil
+}
+
+// get returns the current external template, or the built-in one if none is
+// configured
+func (ts *templateStore) get() *template.Template {
+	ts.mu.RLock()
+	defer ts.mu.RUnlock()
+	if ts.tmpl == nil {
+		return ts.builtin
+	}
+	return ts.tmpl
+}
+
+// loadError returns the error of the last attempt to load the template file,
+// or nil if it succeeded
+func (ts *templateStore) loadError() error {
+	ts.mu.RLock()
+	defer ts.mu.RUnlock()
+	return ts.loadErr
+}
+
+// load parses the template file and, if it is valid, replaces the current template.
+// On error the previous template stays in place.
+func (ts *templateStore) load() error {
+	tmpl, err := ts.parseFile()
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	ts.loadErr = err
+	if err != nil {
+		return err
+	}
+	ts.tmpl = tmpl
+	return nil
+}
+
+// parseFile reads and parses the template file
+func (ts *templateStore) parseFile() (*template.Template, error) {
+	source, err := os.ReadFile(ts.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read template %s: %w", ts.path, err)
+	}
+	tmpl, err := template.New("page").Funcs(templateFuncs).Parse(string(source))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template %s: %w", ts.path, err)
+	}
+	return tmpl, nil
+}
+
+// watch reloads the template whenever the file changes. The parent directory is
+// watched rather than the file itself because many editors save by replacing
+// the file. Reload errors are logged and the last good template is kept.
+func (ts *templateStore) watch() error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	if err := watcher.Add(filepath.Dir(ts.path)); err != nil {
+		watcher.Close()
+		return err
+	}
+
+	go func() {
+		defer watcher.Close()
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if filepath.Clean(event.Name) != filepath.Clean(ts.path) ||
+					!event.Has(fsnotify.Write) && !event.Has(fsnotify.Create) {
+					continue
+				}
+				if err := ts.load(); err != nil {
+					slog.Warn("keeping previous template", "path", ts.path, "err", err)
+					continue
+				}
+				slog.Info("reloaded template", "path", ts.path)
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				slog.Warn("template watcher error", "err", err)
+			}
+		}
+	}()
+
+	return nil
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"html/template"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeTemplate writes a page template to a temporary file and returns its path
+func writeTemplate(t *testing.T, source string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "page.html")
+	if err := os.WriteFile(path, []byte(source), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// replaceFile saves content to path by renaming a temporary file over it, as
+// editors do, so watchers never see it half written
+func replaceFile(t *testing.T, path, content string) {
+	t.Helper()
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// waitForBody requests target until the body contains want, or fails the
+// test after a few seconds
+func waitForBody(t *testing.T, s *Server, target, want string) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		body := get(s, target).Body.String()
+		if strings.Contains(body, want) {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("body never contained %q, last:\n%s", want, body)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestTemplateHotReload(t *testing.T) {
+	path := writeTemplate(t, "<p>layout one: {{.Title}}</p>")
+	s := newTestServer(t, map[string]string{"index.md": "---\ntitle: Home\n---\n"}, func(c *Config) {
+		c.TemplateFile = path
+		c.DevMode = true
+	})
+	if err := s.templates.watch(); err != nil {
+		t.Fatal(err)
+	}
+	waitForBody(t, s, "/", "layout one: Home")
+
+	replaceFile(t, path, "<p>second layout: {{.Title}}</p>")
+	waitForBody(t, s, "/", "second layout: Home")
+
+	// A broken edit is reported but the last good template keeps serving
+	replaceFile(t, path, "<p>{{.Title</p>")
+	deadline := time.Now().Add(5 * time.Second)
+	for s.templates.loadError() == nil {
+		if time.Now().After(deadline) {
+			t.Fatal("broken template was never reloaded")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if body := get(s, "/").Body.String(); !strings.Contains(body, "second layout: Home") {
+		t.Errorf("body after a broken edit = %q, want the previous layout", body)
+	}
+}
+
+func TestTemplateLoadKeepsPrevious(t *testing.T) {
+	path := writeTemplate(t, "good {{.Title}}")
+	ts, err := newTemplateStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	good := ts.get()
+
+	if err := os.WriteFile(path, []byte("bad {{.Title"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ts.load(); err == nil {
+		t.Fatal("load accepted a broken template")
+	}
+	if ts.get() != good {
+		t.Error("broken template replaced the previous one")
+	}
+
+	if err := os.WriteFile(path, []byte("fixed {{.Title}}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ts.load(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if ts.get() == good || ts.loadError() != nil {
+		t.Error("fixed template was not loaded")
+	}
+}
+
+func TestTemplateFuncs(t *testing.T) {
+	year := strconv.Itoa(time.Now().Year())
+	tests := []struct {
+		name   string
+		source string
+		data   interface{}
+		want   string
+	}{
+		{"now", `{{(now).Year}}`, nil, year},
+		{"year", `{{year}}`, nil, year},
+		{"upper", `{{upper .}}`, "Docs", "DOCS"},
+		{"lower", `{{lower .}}`, "Docs", "docs"},
+		{"default with value", `{{default "Untitled" .}}`, "Home", "Home"},
+		{"default with empty string", `{{default "Untitled" .}}`, "", "Untitled"},
+		{"default with nil", `{{default "Untitled" .}}`, nil, "Untitled"},
+		{"default with zero", `{{default 1 .}}`, 0, "1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl, err := template.New("test").Funcs(templateFuncs).Parse(tt.source)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var buf strings.Builder
+			if err := tmpl.Execute(&buf, tt.data); err != nil {
+				t.Fatal(err)
+			}
+			if got := buf.String(); got != tt.want {
+				t.Errorf("rendered %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTemplateFuncsInPageTemplate(t *testing.T) {
+	path := writeTemplate(t, `<footer>&copy; {{year}} {{upper .SiteName}} {{default "no image" .Image}}</footer>`)
+	s := newTestServer(t, map[string]string{"index.md": "# Home\n"}, func(c *Config) {
+		c.TemplateFile = path
+		c.SiteTitle = "My Docs"
+	})
+	want := "&copy; " + strconv.Itoa(time.Now().Year()) + " MY DOCS no image"
+	if body := get(s, "/").Body.String(); !strings.Contains(body, want) {
+		t.Errorf("body = %q, want it to contain %q", body, want)
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// themeScript is served from /__theme.js and loaded in the page head, so the
+// stored theme is applied before the page is drawn. The toggle button stays
+// hidden without it. Without a stored choice there is no data-theme attribute
+// and the stylesheet follows the operating system's preference.
+const themeScript = `(function() {
+    var root = document.documentElement;
+    var stored = null;
+    try { stored = localStorage.getItem('theme'); } catch (e) {}
+    if (stored === 'dark' || stored === 'light') root.dataset.theme = stored;
+
+    document.addEventListener('DOMContentLoaded', function() {
+        var button = document.querySelector('.theme-toggle');
+        if (!button) return;
+        var dark = window.matchMedia('(prefers-color-scheme: dark)');
+        function isDark() {
+            return root.dataset.theme ? root.dataset.theme === 'dark' : dark.matches;
+        }
+        function update() {
+            button.setAttribute('aria-pressed', isDark() ? 'true' : 'false');
+            button.title = isDark() ? 'Switch to light mode' : 'Switch to dark mode';
+        }
+        button.addEventListener('click', function() {
+            root.dataset.theme = isDark() ? 'light' : 'dark';
+            try { localStorage.setItem('theme', root.dataset.theme); } catch (e) {}
+            update();
+        });
+        dark.addEventListener('change', update);
+        button.hidden = false;
+        update();
+    });
+})();
+`
+
+func (s *Server) handleThemeScript(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/javascript")
+	w.Header().Set("Cache-Control", s.config.CacheControl)
+	fmt.Fprint(w, themeScript)
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"log/slog"
+	"net"
+	"net/http"
+)
+
+// tlsListeners serve handler over HTTPS on HTTPS_PORT, with HTTP/3 alongside
+// when enabled. With TLS_REDIRECT a second, plain HTTP server on PORT
+// redirects to it.
+func (s *Server) tlsListeners(handler http.Handler) []listener {
+	addr := s.config.httpsAddr()
+	slog.Info("starting HTTPS server", "addr", addr, "content_dir", s.config.ContentDir)
+	var listeners []listener
+	if s.config.HTTP3 {
+		slog.Info("HTTP/3 enabled")
+		listeners = s.http3Listeners(addr, handler)
+	} else {
+		tlsServer := s.newHTTPServer(addr, handler)
+		listeners = []listener{httpListener(tlsServer, func() error {
+			return tlsServer.ListenAndServeTLS(s.config.TLSCertFile, s.config.TLSKeyFile)
+		})}
+	}
+
+	if s.config.TLSRedirect {
+		redirectServer := s.newHTTPServer(s.config.listenAddr(), http.HandlerFunc(s.redirectToHTTPS))
+		slog.Info("redirecting HTTP to HTTPS", "addr", redirectServer.Addr)
+		listeners = append(listeners, httpListener(redirectServer, redirectServer.ListenAndServe))
+	}
+	return listeners
+}
+
+// redirectToHTTPS permanently redirects every request to the same URL on the
+// HTTPS port
+func (s *Server) redirectToHTTPS(w http.ResponseWriter, r *http.Request) {
+	host := r.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	if s.config.HTTPSPort != "443" {
+		host = net.JoinHostPort(host, s.config.HTTPSPort)
+	}
+	http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusMovedPermanently)
+}
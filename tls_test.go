@@ -0,0 +1,157 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestTLSConfig(t *testing.T) {
+	certFile, keyFile := writeTestCert(t)
+	_, otherKey := writeTestCert(t)
+	garbage := filepath.Join(t.TempDir(), "garbage.pem")
+	if err := os.WriteFile(garbage, []byte("not a certificate"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		cert, key string
+		ok        bool
+	}{
+		{"plain HTTP", "", "", true},
+		{"keypair", certFile, keyFile, true},
+		{"cert only", certFile, "", false},
+		{"key only", "", keyFile, false},
+		{"missing file", filepath.Join(t.TempDir(), "missing.pem"), keyFile, false},
+		{"not PEM", garbage, keyFile, false},
+		{"mismatched key", certFile, otherKey, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := defaultConfig()
+			config.TLSCertFile, config.TLSKeyFile = tt.cert, tt.key
+			if err := config.validate(); (err == nil) != tt.ok {
+				t.Errorf("validate = %v, want ok %v", err, tt.ok)
+			}
+		})
+	}
+}
+
+func TestServesTLS(t *testing.T) {
+	certFile, keyFile := writeTestCert(t)
+	port := freePort(t)
+	s := newTestServer(t, map[string]string{"index.md": "# Home\n"}, func(c *Config) {
+		c.BindAddr = "127.0.0.1"
+		c.Port = port
+		c.TLSCertFile, c.TLSKeyFile = certFile, keyFile
+	})
+	runTestServer(t, s)
+
+	waitForHeader(t, "https://127.0.0.1:"+port+"/", "Content-Type")
+	resp, err := insecureClient.Get("https://127.0.0.1:" + port + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.TLS == nil {
+		t.Errorf("got %d over TLS %v, want 200 over TLS", resp.StatusCode, resp.TLS != nil)
+	}
+
+	// The same port doesn't answer plain HTTP with a page
+	if resp, err := insecureClient.Get("http://127.0.0.1:" + port + "/"); err == nil {
+		resp.Body.Close()
+		if resp.StatusCode == http.StatusOK {
+			t.Error("plain HTTP served a page on the TLS port")
+		}
+	}
+}
+
+func TestRedirectToHTTPS(t *testing.T) {
+	tests := []struct {
+		name      string
+		httpsPort string
+		target    string
+		want      string
+	}{
+		{"custom port", "8443", "http://example.com:8080/docs?q=1", "https://example.com:8443/docs?q=1"},
+		{"default port", "443", "http://example.com:8080/docs", "https://example.com/docs"},
+		{"host without port", "8443", "http://example.com/", "https://example.com:8443/"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Server{config: defaultConfig()}
+			s.config.HTTPSPort = tt.httpsPort
+			w := httptest.NewRecorder()
+			s.redirectToHTTPS(w, httptest.NewRequest(http.MethodGet, tt.target, nil))
+			if w.Code != http.StatusMovedPermanently {
+				t.Errorf("status = %d, want 301", w.Code)
+			}
+			if got := w.Header().Get("Location"); got != tt.want {
+				t.Errorf("Location = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHTTPSRedirectListener(t *testing.T) {
+	certFile, keyFile := writeTestCert(t)
+	httpPort, httpsPort := freePort(t), freePort(t)
+	s := newTestServer(t, map[string]string{"index.md": "# Home\n", "docs.md": "# Docs\n"}, func(c *Config) {
+		c.BindAddr = "127.0.0.1"
+		c.Port, c.HTTPSPort = httpPort, httpsPort
+		c.TLSCertFile, c.TLSKeyFile = certFile, keyFile
+		c.TLSRedirect = true
+	})
+	runTestServer(t, s)
+
+	tests := []struct {
+		target string
+		want   string
+	}{
+		{"/", "https://127.0.0.1:" + httpsPort + "/"},
+		{"/docs?q=a+b", "https://127.0.0.1:" + httpsPort + "/docs?q=a+b"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			location := waitForHeader(t, "http://127.0.0.1:"+httpPort+tt.target, "Location")
+			if location != tt.want {
+				t.Errorf("Location = %q, want %q", location, tt.want)
+			}
+		})
+	}
+
+	// Both listeners run side by side
+	waitForHeader(t, "https://127.0.0.1:"+httpsPort+"/docs", "Content-Type")
+}
+
+func TestTLSRedirectConfig(t *testing.T) {
+	certFile, keyFile := writeTestCert(t)
+	tests := []struct {
+		name      string
+		cert      bool
+		httpsPort string
+		ok        bool
+	}{
+		{"separate ports", true, "8443", true},
+		{"without TLS", false, "8443", false},
+		{"no HTTPS port", true, "", false},
+		{"same port", true, "8080", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := defaultConfig()
+			config.Port = "8080"
+			config.HTTPSPort = tt.httpsPort
+			config.TLSRedirect = true
+			if tt.cert {
+				config.TLSCertFile, config.TLSKeyFile = certFile, keyFile
+			}
+			if err := config.validate(); (err == nil) != tt.ok {
+				t.Errorf("validate = %v, want ok %v", err, tt.ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bytes"
+	"html/template"
+	"strings"
+
+	"github.com/gomarkdown/markdown/ast"
+)
+
+// tocMarker is replaced with the table of contents when it is alone in a paragraph
+const tocMarker = "[TOC]"
+
+// Headings from tocMinLevel to tocMaxLevel are listed in the table of contents
+const (
+	tocMinLevel = 2
+	tocMaxLevel = 4
+)
+
+// tocEntry is a heading listed in the table of contents
+type tocEntry struct {
+	Level int
+	ID    string
+	Text  string
+}
+
+// collectTOC returns the document's H2-H4 headings in order
+func collectTOC(doc ast.Node) []tocEntry {
+	var entries []tocEntry
+	ast.WalkFunc(doc, func(node ast.Node, entering bool) ast.WalkStatus {
+		heading, ok := node.(*ast.Heading)
+		if !ok || !entering || heading.Level < tocMinLevel || heading.Level > tocMaxLevel || heading.HeadingID == "" {
+			return ast.GoToNext
+		}
+		entries = append(entries, tocEntry{
+			Level: heading.Level,
+			ID:    heading.HeadingID,
+			Text:  plainText(heading),
+		})
+		return ast.SkipChildren
+	})
+	return entries
+}
+
+// plainText returns the text of node with inline formatting stripped
+func plainText(node ast.Node) string {
+	var buf bytes.Buffer
+	ast.WalkFunc(node, func(child ast.Node, entering bool) ast.WalkStatus {
+		switch child := child.(type) {
+		case *ast.Text:
+			buf.Write(child.Literal)
+		case *ast.Code:
+			buf.Write(child.Literal)
+		}
+		return ast.GoToNext
+	})
+	return strings.TrimSpace(buf.String())
+}
+
+// renderTOC renders entries as nested lists of anchor links. A heading deeper
+// than the one before it starts a nested list, so skipped levels (an H4 right
+// after an H2) are nested one level rather than left out.
+func renderTOC(entries []tocEntry) string {
+	if len(entries) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	var levels []int
+	for _, entry := range entries {
+		switch {
+		case len(levels) == 0:
+			b.WriteString(`<ul class="toc">`)
+			levels = append(levels, entry.Level)
+		case entry.Level > levels[len(levels)-1]:
+			b.WriteString("<ul>")
+			levels = append(levels, entry.Level)
+		default:
+			for len(levels) > 1 && entry.Level < levels[len(levels)-1] {
+				b.WriteString("</li></ul>")
+				levels = levels[:len(levels)-1]
+			}
+			b.WriteString("</li>")
+		}
+		b.WriteString(`<li><a href="#`)
+		b.WriteString(template.HTMLEscapeString(entry.ID))
+		b.WriteString(`">`)
+		b.WriteString(template.HTMLEscapeString(entry.Text))
+		b.WriteString("</a>")
+	}
+	for range levels {
+		b.WriteString("</li></ul>")
+	}
+	return b.String()
+}
+
+// replaceTOCMarkers replaces every paragraph containing only [TOC] with toc
+func replaceTOCMarkers(doc ast.Node, toc string) {
+	var markers []ast.Node
+	ast.WalkFunc(doc, func(node ast.Node, entering bool) ast.WalkStatus {
+		if para, ok := node.(*ast.Paragraph); ok && entering {
+			if plainText(para) == tocMarker {
+				markers = append(markers, para)
+			}
+			return ast.SkipChildren
+		}
+		return ast.GoToNext
+	})
+
+	for _, marker := range markers {
+		parent := marker.GetParent()
+		children := parent.GetChildren()
+		for i, child := range children {
+			if child == marker {
+				block := &ast.HTMLBlock{Leaf: ast.Leaf{Literal: []byte(toc)}}
+				block.SetParent(parent)
+				children[i] = block
+			}
+		}
+	}
+}
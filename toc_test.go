@@ -0,0 +1,103 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/gomarkdown/markdown/ast"
+)
+
+// headingWithID returns heading(level, text) with the given heading ID
+func headingWithID(level int, text, id string) *ast.Heading {
+	h := heading(level, text)
+	h.HeadingID = id
+	return h
+}
+
+func TestCollectTOC(t *testing.T) {
+	// ## Install `go` *now*
+	formatted := &ast.Heading{Level: 2, HeadingID: "install-go-now"}
+	ast.AppendChild(formatted, &ast.Text{Leaf: ast.Leaf{Literal: []byte("Install ")}})
+	ast.AppendChild(formatted, &ast.Code{Leaf: ast.Leaf{Literal: []byte("go")}})
+	ast.AppendChild(formatted, &ast.Text{Leaf: ast.Leaf{Literal: []byte(" ")}})
+	emph := &ast.Emph{}
+	ast.AppendChild(emph, &ast.Text{Leaf: ast.Leaf{Literal: []byte("now")}})
+	ast.AppendChild(formatted, emph)
+
+	doc := document(
+		headingWithID(1, "Title", "title"),
+		formatted,
+		headingWithID(3, "Linux", "linux"),
+		headingWithID(4, "Debian", "debian"),
+		headingWithID(5, "Too deep", "too-deep"),
+		headingWithID(2, "Usage", "usage"),
+		heading(2, "No ID"),
+	)
+	want := []tocEntry{
+		{2, "install-go-now", "Install go now"},
+		{3, "linux", "Linux"},
+		{4, "debian", "Debian"},
+		{2, "usage", "Usage"},
+	}
+	if got := collectTOC(doc); !reflect.DeepEqual(got, want) {
+		t.Errorf("collectTOC = %+v, want %+v", got, want)
+	}
+}
+
+func TestRenderTOC(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []tocEntry
+		want    string
+	}{
+		{"empty", nil, ""},
+		{
+			"flat",
+			[]tocEntry{{2, "a", "A"}, {2, "b", "B"}},
+			`<ul class="toc"><li><a href="#a">A</a></li><li><a href="#b">B</a></li></ul>`,
+		},
+		{
+			"nested",
+			[]tocEntry{{2, "a", "A"}, {3, "a1", "A1"}, {4, "a1x", "A1x"}, {3, "a2", "A2"}, {2, "b", "B"}},
+			`<ul class="toc"><li><a href="#a">A</a><ul><li><a href="#a1">A1</a><ul><li><a href="#a1x">A1x</a>` +
+				`</li></ul></li><li><a href="#a2">A2</a></li></ul></li><li><a href="#b">B</a></li></ul>`,
+		},
+		{
+			"skipped level",
+			[]tocEntry{{2, "a", "A"}, {4, "deep", "Deep"}, {2, "b", "B"}},
+			`<ul class="toc"><li><a href="#a">A</a><ul><li><a href="#deep">Deep</a></li></ul></li><li><a href="#b">B</a></li></ul>`,
+		},
+		{
+			"escaped",
+			[]tocEntry{{2, "x", "<b>&</b>"}},
+			`<ul class="toc"><li><a href="#x">&lt;b&gt;&amp;&lt;/b&gt;</a></li></ul>`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := renderTOC(tt.entries); got != tt.want {
+				t.Errorf("renderTOC =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReplaceTOCMarkers(t *testing.T) {
+	paragraph := func(text string) *ast.Paragraph {
+		p := &ast.Paragraph{}
+		ast.AppendChild(p, &ast.Text{Leaf: ast.Leaf{Literal: []byte(text)}})
+		return p
+	}
+	marker, other := paragraph("[TOC]"), paragraph("See [TOC] below")
+	doc := document(marker, other)
+
+	replaceTOCMarkers(doc, "<ul>toc</ul>")
+	children := doc.GetChildren()
+	block, ok := children[0].(*ast.HTMLBlock)
+	if !ok || string(block.Literal) != "<ul>toc</ul>" || block.GetParent() != doc {
+		t.Errorf("marker paragraph became %#v, want the table of contents", children[0])
+	}
+	if children[1] != other {
+		t.Error("paragraph mentioning [TOC] was replaced")
+	}
+}
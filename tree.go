@@ -0,0 +1,188 @@
+package main
+
+import (
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// contentTree is a snapshot of the content directory: every file and
+// directory except hidden ones. Listings, the sidebar, page navigation,
+// feeds, search and the sitemap all read it instead of the filesystem, so
+// they see the same content.
+type contentTree struct {
+	// dirs holds the entries of each directory by name, keyed by the
+	// directory's slash-separated path relative to the content directory,
+	// "." for the root
+	dirs map[string][]fs.DirEntry
+	// markdown lists the markdown files in walk order
+	markdown []treeFile
+	// files indexes markdown by relative path
+	files map[string]int
+	taken time.Time
+}
+
+// treeFile is a markdown file in the content tree
+type treeFile struct {
+	path    string
+	relPath string
+	info    fs.FileInfo
+	page    pageInfo
+}
+
+// pageInfo is what listings, the sidebar and page navigation need from a
+// page's frontmatter, read once when the file is scanned
+type pageInfo struct {
+	draft bool
+	// title is the frontmatter title or first H1, "" if the page has neither
+	title    string
+	weight   int
+	weighted bool
+}
+
+// treeCache holds the content tree for TREE_CACHE_TTL, or until the content
+// watcher sees a change when live reload is enabled
+type treeCache struct {
+	mu      sync.Mutex
+	tree    *contentTree
+	watched bool
+	// last is the most recent scan, kept after invalidation so unchanged
+	// files needn't be read again
+	last *contentTree
+}
+
+// contentTree returns the snapshot of the content directory, scanning it again
+// when the cached one is out of date
+func (s *Server) contentTree() (*contentTree, error) {
+	s.treeCache.mu.Lock()
+	defer s.treeCache.mu.Unlock()
+	if tree := s.treeCache.tree; tree != nil {
+		ttl := seconds(s.config.TreeCacheTTL)
+		if s.treeCache.watched || ttl > 0 && time.Since(tree.taken) < ttl {
+			return tree, nil
+		}
+	}
+
+	tree, err := s.scanContentTree(s.treeCache.last)
+	if err != nil {
+		return nil, err
+	}
+	s.treeCache.tree, s.treeCache.last = tree, tree
+	return tree, nil
+}
+
+// invalidateContentTree makes the next contentTree call scan the content
+// directory again. The content watcher calls it on every change.
+func (s *Server) invalidateContentTree() {
+	s.treeCache.mu.Lock()
+	s.treeCache.tree = nil
+	s.treeCache.mu.Unlock()
+}
+
+// scanContentTree walks the content directory, skipping hidden files and
+// directories. Pages are read for their frontmatter unless previous, an
+// earlier scan, has them with the same size and modification time.
+func (s *Server) scanContentTree(previous *contentTree) (*contentTree, error) {
+	tree := &contentTree{dirs: make(map[string][]fs.DirEntry), files: make(map[string]int), taken: time.Now()}
+	err := filepath.WalkDir(s.config.ContentDir, func(filePath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if filePath == s.config.ContentDir {
+			tree.dirs["."] = nil
+			return nil
+		}
+		if strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		relPath, err := filepath.Rel(s.config.ContentDir, filePath)
+		if err != nil {
+			return err
+		}
+		relPath = filepath.ToSlash(relPath)
+		parent := path.Dir(relPath)
+		tree.dirs[parent] = append(tree.dirs[parent], d)
+		if d.IsDir() {
+			tree.dirs[relPath] = nil
+			return nil
+		}
+		if filepath.Ext(filePath) == ".md" {
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			file := treeFile{path: filePath, relPath: relPath, info: info}
+			if old, ok := previous.file(relPath); ok && old.info.ModTime().Equal(info.ModTime()) && old.info.Size() == info.Size() {
+				file.page = old.page
+			} else {
+				file.page = s.readPageInfo(filePath, relPath)
+			}
+			tree.files[relPath] = len(tree.markdown)
+			tree.markdown = append(tree.markdown, file)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return tree, nil
+}
+
+// readPageInfo reads the frontmatter of the page at filePath. Pages that
+// can't be read are judged by their path alone so listings still show them.
+func (s *Server) readPageInfo(filePath, relPath string) pageInfo {
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return pageInfo{draft: isDraft(nil, relPath)}
+	}
+	meta, body, _ := parseFrontmatter(content)
+	page := pageInfo{draft: isDraft(meta, relPath)}
+	if title := s.pageTitle(meta, body); title != defaultTitle {
+		page.title = title
+	}
+	page.weight, page.weighted = metaInt(meta, "weight")
+	return page
+}
+
+// file returns the markdown file at relPath, relative to the content directory
+func (tree *contentTree) file(relPath string) (treeFile, bool) {
+	if tree == nil {
+		return treeFile{}, false
+	}
+	i, ok := tree.files[relPath]
+	if !ok {
+		return treeFile{}, false
+	}
+	return tree.markdown[i], true
+}
+
+// treeFile returns the markdown file at relPath from the content tree
+func (s *Server) treeFile(relPath string) (treeFile, bool) {
+	tree, err := s.contentTree()
+	if err != nil {
+		return treeFile{}, false
+	}
+	return tree.file(path.Clean(strings.TrimPrefix(relPath, "/")))
+}
+
+// readContentDir returns the entries of the directory at relDir, relative to
+// the content directory, sorted by name as os.ReadDir does. Hidden entries are
+// left out.
+func (s *Server) readContentDir(relDir string) ([]fs.DirEntry, error) {
+	tree, err := s.contentTree()
+	if err != nil {
+		return nil, err
+	}
+	entries, ok := tree.dirs[path.Clean(strings.TrimPrefix(relDir, "/"))]
+	if !ok {
+		return nil, fs.ErrNotExist
+	}
+	return entries, nil
+}
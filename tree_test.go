@@ -0,0 +1,71 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestContentTreePageInfo(t *testing.T) {
+	s := newTestServer(t, map[string]string{
+		"index.md":          "# Home\n",
+		"guide/setup.md":    "---\ntitle: Setup\nweight: 2\n---\n",
+		"guide/draft.md":    "---\ntitle: Draft\ndraft: true\n---\n",
+		"_partials/note.md": "# Note\n",
+		"untitled.md":       "No heading here.\n",
+	}, nil)
+	tests := []struct {
+		relPath string
+		want    pageInfo
+	}{
+		{"index.md", pageInfo{title: "Home"}},
+		{"guide/setup.md", pageInfo{title: "Setup", weight: 2, weighted: true}},
+		{"guide/draft.md", pageInfo{title: "Draft", draft: true}},
+		{"_partials/note.md", pageInfo{title: "Note", draft: true}},
+		{"untitled.md", pageInfo{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.relPath, func(t *testing.T) {
+			file, ok := s.treeFile(tt.relPath)
+			if !ok {
+				t.Fatalf("%s missing from the content tree", tt.relPath)
+			}
+			if file.page != tt.want {
+				t.Errorf("page = %+v, want %+v", file.page, tt.want)
+			}
+		})
+	}
+}
+
+func TestContentTreeSnapshotTitles(t *testing.T) {
+	s := newTestServer(t, map[string]string{
+		"index.md":       "# Home\n",
+		"guide/alpha.md": "# Alpha\n",
+		"guide/beta.md":  "# Beta\n",
+	}, func(c *Config) { c.TreeCacheTTL = 3600 })
+	title := func() string {
+		_, next := s.pageNeighbors("guide/alpha.md")
+		if next == nil {
+			t.Fatal("no next page")
+		}
+		return next.Title
+	}
+	if got := title(); got != "Beta" {
+		t.Fatalf("title = %q, want Beta", got)
+	}
+
+	// Pages aren't read again while the scan is cached
+	writeFiles(t, s.config.ContentDir, map[string]string{"guide/beta.md": "# Second\n"})
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(filepath.Join(s.config.ContentDir, "guide/beta.md"), later, later); err != nil {
+		t.Fatal(err)
+	}
+	if got := title(); got != "Beta" {
+		t.Errorf("title = %q before the tree is scanned again, want Beta", got)
+	}
+	s.invalidateContentTree()
+	if got := title(); got != "Second" {
+		t.Errorf("title = %q after the tree is scanned again, want Second", got)
+	}
+}
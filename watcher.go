@@ -0,0 +1,105 @@
+package main
+
+import (
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// watchContent watches the content directory tree and calls contentChanged
+// whenever a markdown or CSS file is created, modified, removed or renamed.
+// Newly created subdirectories are added to the watch as they appear.
+func (s *Server) watchContent() error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+
+	if err := addWatchTree(watcher, s.config.ContentDir); err != nil {
+		watcher.Close()
+		return err
+	}
+	s.treeCache.mu.Lock()
+	s.treeCache.watched = true
+	s.treeCache.mu.Unlock()
+
+	go func() {
+		defer watcher.Close()
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				s.handleWatchEvent(watcher, event)
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				slog.Warn("content watcher error", "err", err)
+			}
+		}
+	}()
+
+	return nil
+}
+
+func (s *Server) handleWatchEvent(watcher *fsnotify.Watcher, event fsnotify.Event) {
+	if !event.Has(fsnotify.Chmod) || event.Has(fsnotify.Write) {
+		s.invalidateContentTree()
+	}
+
+	// New directories need their own watch; removed ones are dropped by fsnotify
+	if event.Has(fsnotify.Create) {
+		if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+			if err := addWatchTree(watcher, event.Name); err != nil {
+				slog.Warn("failed to watch directory", "path", event.Name, "err", err)
+			}
+			s.contentChanged(event.Name)
+			return
+		}
+	}
+
+	if event.Has(fsnotify.Chmod) && !event.Has(fsnotify.Write) {
+		return
+	}
+
+	switch filepath.Ext(event.Name) {
+	case ".md", ".css":
+		s.contentChanged(event.Name)
+	}
+}
+
+// contentChanged is called by the watcher for every relevant content change
+func (s *Server) contentChanged(path string) {
+	if s.config.CrossRefs && filepath.Ext(path) == ".md" {
+		if err := s.buildRefIndex(); err != nil {
+			slog.Error("failed to rebuild reference index", "err", err)
+		}
+	}
+	if filepath.Ext(path) == ".md" {
+		s.rebuildSearchIndex()
+	}
+	if s.liveReload != nil {
+		s.liveReload.broadcast()
+	}
+}
+
+// addWatchTree adds root and all of its non-hidden subdirectories to the watcher
+func addWatchTree(watcher *fsnotify.Watcher, root string) error {
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if path != root && d.Name()[0] == '.' {
+			return filepath.SkipDir
+		}
+		return watcher.Add(path)
+	})
+}
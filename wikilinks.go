@@ -0,0 +1,130 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/gomarkdown/markdown/ast"
+)
+
+// wikiLink matches [[Page Name]] and [[Page Name|link text]]
+var wikiLink = regexp.MustCompile(`\[\[([^\[\]|]+)(?:\|([^\[\]]+))?\]\]`)
+
+// convertWikiLinks turns wiki links in the document's text into links to the
+// slugified clean URL of the page: [[Getting Started]] links to
+// /getting-started, and [[guides/Setup#Install|setup]] to /guides/setup#install
+// with the text "setup". Links to pages that don't exist get the "broken"
+// class. Code spans and code blocks are separate node types, so wiki links in
+// code samples are left as written.
+func (s *Server) convertWikiLinks(doc ast.Node) {
+	var texts []*ast.Text
+	ast.WalkFunc(doc, func(node ast.Node, entering bool) ast.WalkStatus {
+		if text, ok := node.(*ast.Text); ok && entering && wikiLink.Match(text.Literal) {
+			texts = append(texts, text)
+		}
+		return ast.GoToNext
+	})
+
+	// Split each text node around its wiki links once the walk is done
+	for _, text := range texts {
+		parent := text.GetParent()
+		literal := text.Literal
+		var nodes []ast.Node
+		last := 0
+		for _, match := range wikiLink.FindAllSubmatchIndex(literal, -1) {
+			if match[0] > last {
+				nodes = append(nodes, &ast.Text{Leaf: ast.Leaf{Literal: literal[last:match[0]]}})
+			}
+			target := strings.TrimSpace(string(literal[match[2]:match[3]]))
+			label := target
+			if match[4] >= 0 {
+				label = strings.TrimSpace(string(literal[match[4]:match[5]]))
+			}
+			nodes = append(nodes, s.wikiLinkNode(target, label))
+			last = match[1]
+		}
+		if last < len(literal) {
+			nodes = append(nodes, &ast.Text{Leaf: ast.Leaf{Literal: literal[last:]}})
+		}
+
+		var children []ast.Node
+		for _, child := range parent.GetChildren() {
+			if child == ast.Node(text) {
+				for _, node := range nodes {
+					node.SetParent(parent)
+				}
+				children = append(children, nodes...)
+			} else {
+				children = append(children, child)
+			}
+		}
+		parent.SetChildren(children)
+	}
+}
+
+// wikiLinkNode returns a link to the page named by target with label as its text
+func (s *Server) wikiLinkNode(target, label string) ast.Node {
+	page, fragment, _ := strings.Cut(target, "#")
+	slug := slugifyPath(page)
+
+	link := &ast.Link{Destination: []byte(s.urlPrefix + "/" + slug)}
+	if fragment != "" {
+		link.Destination = append(link.Destination, "#"+slugify(fragment)...)
+	}
+	if slug != "" && !s.pageExists(slug) {
+		link.AdditionalAttributes = []string{`class="broken"`}
+	}
+	text := &ast.Text{Leaf: ast.Leaf{Literal: []byte(label)}}
+	text.SetParent(link)
+	link.Children = []ast.Node{text}
+	return link
+}
+
+// slugify lower-cases s and joins its words with hyphens, dropping punctuation:
+// "Getting Started!" becomes "getting-started"
+func slugify(s string) string {
+	var b strings.Builder
+	hyphen := false
+	for _, r := range strings.ToLower(s) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsNumber(r):
+			if hyphen && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			hyphen = false
+		case r == ' ' || r == '-' || r == '_':
+			hyphen = true
+		}
+	}
+	return b.String()
+}
+
+// slugifyPath slugifies each segment of a slash-separated page path
+func slugifyPath(page string) string {
+	var segments []string
+	for _, segment := range strings.Split(page, "/") {
+		if slug := slugify(segment); slug != "" {
+			segments = append(segments, slug)
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// pageExists reports whether slug names a page, either slug.md or a directory
+// with a default document
+func (s *Server) pageExists(slug string) bool {
+	for _, name := range []string{slug + ".md", filepath.Join(slug, s.indexFile)} {
+		path := filepath.Join(s.config.ContentDir, filepath.FromSlash(name))
+		if !s.isPathSafe(path) {
+			return false
+		}
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"encoding/xml"
+	"net/http"
+	"time"
+)
+
+const (
+	feedFormatRSS  = "rss"
+	feedFormatAtom = "atom"
+)
+
+// rssFeed is an RSS 2.0 document (https://www.rssboard.org/rss-specification)
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title       string    `xml:"title"`
+	Link        string    `xml:"link"`
+	Description string    `xml:"description"`
+	Items       []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string `xml:"title"`
+	Link        string `xml:"link"`
+	GUID        string `xml:"guid"`
+	Description string `xml:"description,omitempty"`
+	PubDate     string `xml:"pubDate"`
+}
+
+// atomFeed is an Atom 1.0 document (RFC 4287)
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Title   string      `xml:"title"`
+	ID      string      `xml:"id"`
+	Updated string      `xml:"updated"`
+	Author  atomAuthor  `xml:"author"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+}
+
+type atomEntry struct {
+	Title   string      `xml:"title"`
+	ID      string      `xml:"id"`
+	Updated string      `xml:"updated"`
+	Author  *atomAuthor `xml:"author,omitempty"`
+	Link    atomLink    `xml:"link"`
+	Summary string      `xml:"summary,omitempty"`
+	Content atomContent `xml:"content"`
+}
+
+type atomContent struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
+
+// handleXMLFeed serves the most recent dated pages as an RSS 2.0 or Atom feed,
+// depending on FEED_FORMAT
+func (s *Server) handleXMLFeed(w http.ResponseWriter, r *http.Request) {
+	s.serveXMLFeed(w, r, s.config.FeedFormat, "/feed.xml", &s.xmlFeedCache)
+}
+
+// handleAtomFeed serves the most recent dated pages as an Atom feed whatever
+// the FEED_FORMAT, for readers that prefer Atom
+func (s *Server) handleAtomFeed(w http.ResponseWriter, r *http.Request) {
+	s.serveXMLFeed(w, r, feedFormatAtom, "/feed.atom", &s.atomFeedCache)
+}
+
+// serveXMLFeed writes the feed in the given format, served at feedPath, from
+// cache when the content hasn't changed
+func (s *Server) serveXMLFeed(w http.ResponseWriter, r *http.Request, format, feedPath string, cache *feedCache) {
+	fingerprint, err := s.combinedFingerprint()
+	if err != nil {
+		http.Error(w, "Error reading content", http.StatusInternalServerError)
+		return
+	}
+
+	// Links are absolute, so a feed built for another host can't be reused
+	baseURL := s.requestBaseURL(r)
+	data, err := cache.get(baseURL+" "+fingerprint, func() ([]byte, error) {
+		posts, err := s.collectPosts(baseURL, s.config.FeedLimit)
+		if err != nil {
+			return nil, err
+		}
+
+		var feed interface{}
+		if format == feedFormatAtom {
+			feed = s.buildAtomFeed(baseURL, posts, feedPath)
+		} else {
+			feed = s.buildRSSFeed(baseURL, posts)
+		}
+		data, err := xml.MarshalIndent(feed, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		return append([]byte(xml.Header), data...), nil
+	})
+	if err != nil {
+		http.Error(w, "Error generating feed", http.StatusInternalServerError)
+		return
+	}
+
+	if format == feedFormatAtom {
+		w.Header().Set("Content-Type", "application/atom+xml; charset=utf-8")
+	} else {
+		w.Header().Set("Content-Type", "application/rss+xml; charset=utf-8")
+	}
+	w.Header().Set("Cache-Control", s.config.CacheControl)
+	w.Write(data)
+}
+
+// buildRSSFeed converts posts into an RSS 2.0 document for the site at baseURL
+func (s *Server) buildRSSFeed(baseURL string, posts []feedPost) rssFeed {
+	feed := rssFeed{
+		Version: "2.0",
+		Channel: rssChannel{
+			Title:       s.config.SiteTitle,
+			Link:        baseURL + "/",
+			Description: s.siteDescription(),
+		},
+	}
+	for _, post := range posts {
+		feed.Channel.Items = append(feed.Channel.Items, rssItem{
+			Title:       post.Title,
+			Link:        post.URL,
+			GUID:        post.URL,
+			Description: post.Description,
+			PubDate:     post.Date.Format(time.RFC1123Z),
+		})
+	}
+	return feed
+}
+
+// siteDescription returns SITE_DESCRIPTION, falling back to the site title
+func (s *Server) siteDescription() string {
+	if s.config.SiteDescription != "" {
+		return s.config.SiteDescription
+	}
+	return s.config.SiteTitle
+}
+
+// buildAtomFeed converts posts into an Atom document served at feedPath on the
+// site at baseURL. The feed's updated time is the date of the newest post, and
+// its author the site title; posts with an author frontmatter name their own.
+func (s *Server) buildAtomFeed(baseURL string, posts []feedPost, feedPath string) atomFeed {
+	updated := time.Unix(0, 0).UTC()
+	if len(posts) > 0 {
+		updated = posts[0].Date
+	}
+	feed := atomFeed{
+		Title:   s.config.SiteTitle,
+		ID:      baseURL + "/",
+		Updated: updated.Format(time.RFC3339),
+		Author:  atomAuthor{Name: s.config.SiteTitle},
+		Links: []atomLink{
+			{Href: baseURL + "/"},
+			{Href: baseURL + feedPath, Rel: "self"},
+		},
+	}
+	for _, post := range posts {
+		entry := atomEntry{
+			Title:   post.Title,
+			ID:      post.URL,
+			Updated: post.Date.Format(time.RFC3339),
+			Link:    atomLink{Href: post.URL},
+			Summary: post.Description,
+			Content: atomContent{Type: "html", Body: post.ContentHTML},
+		}
+		if post.Author != "" {
+			entry.Author = &atomAuthor{Name: post.Author}
+		}
+		feed.Entries = append(feed.Entries, entry)
+	}
+	return feed
+}
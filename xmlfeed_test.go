@@ -0,0 +1,215 @@
+package main
+
+import (
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRSSFeed(t *testing.T) {
+	s := newTestServer(t, feedFixtures, func(c *Config) {
+		c.SiteTitle = "Test Site"
+		c.SiteDescription = "Notes and posts"
+		c.SiteBaseURL = "https://example.com"
+	})
+	w := get(s, "/feed.xml")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", w.Code)
+	}
+	if got := w.Header().Get("Content-Type"); got != "application/rss+xml; charset=utf-8" {
+		t.Errorf("Content-Type = %q", got)
+	}
+	if !strings.HasPrefix(w.Body.String(), xml.Header) {
+		t.Error("feed has no XML declaration")
+	}
+
+	// Decode with a schema of its own so the test checks the RSS 2.0 element
+	// names rather than the structs the feed is built from
+	var feed struct {
+		XMLName xml.Name `xml:"rss"`
+		Version string   `xml:"version,attr"`
+		Channel struct {
+			Title       string `xml:"title"`
+			Link        string `xml:"link"`
+			Description string `xml:"description"`
+			Items       []struct {
+				Title       string `xml:"title"`
+				Link        string `xml:"link"`
+				GUID        string `xml:"guid"`
+				Description string `xml:"description"`
+				PubDate     string `xml:"pubDate"`
+			} `xml:"item"`
+		} `xml:"channel"`
+	}
+	if err := xml.Unmarshal(w.Body.Bytes(), &feed); err != nil {
+		t.Fatalf("invalid XML: %v", err)
+	}
+	if feed.Version != "2.0" {
+		t.Errorf("version = %q, want 2.0", feed.Version)
+	}
+	channel := feed.Channel
+	if channel.Title != "Test Site" || channel.Link != "https://example.com/" || channel.Description != "Notes and posts" {
+		t.Errorf("channel = %q %q %q, want the configured site", channel.Title, channel.Link, channel.Description)
+	}
+
+	want := []struct {
+		title, link, description string
+		date                     time.Time
+	}{
+		{"Second Post", "https://example.com/posts/second", "", time.Date(2024, 2, 20, 0, 0, 0, 0, time.UTC)},
+		{"First Post", "https://example.com/posts/first", "The first one", time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC)},
+	}
+	if len(channel.Items) != len(want) {
+		t.Fatalf("got %d items, want %d", len(channel.Items), len(want))
+	}
+	for i, item := range channel.Items {
+		if item.Title != want[i].title || item.Link != want[i].link || item.GUID != want[i].link {
+			t.Errorf("item %d = %q %q %q, want %q at %q", i, item.Title, item.Link, item.GUID, want[i].title, want[i].link)
+		}
+		if want[i].description != "" && item.Description != want[i].description {
+			t.Errorf("item %d description = %q, want %q", i, item.Description, want[i].description)
+		}
+		date, err := time.Parse(time.RFC1123Z, item.PubDate)
+		if err != nil {
+			t.Errorf("item %d pubDate %q is not RFC 1123Z: %v", i, item.PubDate, err)
+		} else if !date.Equal(want[i].date) {
+			t.Errorf("item %d pubDate = %v, want %v", i, date, want[i].date)
+		}
+	}
+}
+
+func TestFeedBaseURL(t *testing.T) {
+	tests := []struct {
+		name        string
+		siteBaseURL string
+		trustProxy  bool
+		host        string
+		header      map[string]string
+		want        string
+	}{
+		{"site base url", "https://docs.example.com", false, "localhost:8080", nil, "https://docs.example.com/posts/first"},
+		{"request host", "", false, "localhost:8080", nil, "http://localhost:8080/posts/first"},
+		{"untrusted proxy headers", "", false, "internal:8080", map[string]string{"X-Forwarded-Proto": "https", "X-Forwarded-Host": "docs.example.com"}, "http://internal:8080/posts/first"},
+		{"trusted proxy headers", "", true, "internal:8080", map[string]string{"X-Forwarded-Proto": "https", "X-Forwarded-Host": "docs.example.com"}, "https://docs.example.com/posts/first"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServer(t, feedFixtures, func(c *Config) {
+				c.SiteBaseURL = tt.siteBaseURL
+				c.TrustProxy = tt.trustProxy
+			})
+			r := httptest.NewRequest(http.MethodGet, "/feed.xml", nil)
+			r.Host = tt.host
+			for key, value := range tt.header {
+				r.Header.Set(key, value)
+			}
+			if body := serve(s, r).Body.String(); !strings.Contains(body, "<link>"+tt.want+"</link>") {
+				t.Errorf("feed has no link to %s:\n%s", tt.want, body)
+			}
+		})
+	}
+}
+
+func TestFeedCachedPerHost(t *testing.T) {
+	s := newTestServer(t, feedFixtures, nil)
+	for _, host := range []string{"one.example.com", "two.example.com"} {
+		r := httptest.NewRequest(http.MethodGet, "/feed.xml", nil)
+		r.Host = host
+		if body := serve(s, r).Body.String(); !strings.Contains(body, "<link>http://"+host+"/</link>") {
+			t.Errorf("feed requested from %s links elsewhere:\n%s", host, body)
+		}
+	}
+}
+
+func TestAtomFeed(t *testing.T) {
+	files := map[string]string{
+		"posts/first.md":  "---\ntitle: First Post\ndate: 2024-01-10\ndescription: The first one\n---\n\nHello\n",
+		"posts/second.md": "---\ntitle: Second Post\ndate: 2024-02-20T09:30:00Z\nauthor: Jane Doe\n---\n\nWorld\n",
+	}
+	tests := []struct {
+		name   string
+		path   string
+		format string
+	}{
+		{"feed.atom", "/feed.atom", feedFormatRSS},
+		{"feed.xml as atom", "/feed.xml", feedFormatAtom},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServer(t, files, func(c *Config) {
+				c.SiteTitle = "Test Site"
+				c.SiteBaseURL = "https://example.com"
+				c.FeedFormat = tt.format
+			})
+			w := get(s, tt.path)
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, want 200", w.Code)
+			}
+			if got := w.Header().Get("Content-Type"); got != "application/atom+xml; charset=utf-8" {
+				t.Errorf("Content-Type = %q", got)
+			}
+
+			// RFC 4287 element names, checked independently of the feed's structs
+			type link struct {
+				Href string `xml:"href,attr"`
+				Rel  string `xml:"rel,attr"`
+			}
+			var feed struct {
+				XMLName xml.Name `xml:"http://www.w3.org/2005/Atom feed"`
+				Title   string   `xml:"title"`
+				ID      string   `xml:"id"`
+				Updated string   `xml:"updated"`
+				Author  []string `xml:"author>name"`
+				Links   []link   `xml:"link"`
+				Entries []struct {
+					Title   string   `xml:"title"`
+					ID      string   `xml:"id"`
+					Updated string   `xml:"updated"`
+					Author  []string `xml:"author>name"`
+					Link    link     `xml:"link"`
+					Summary string   `xml:"summary"`
+					Content struct {
+						Type string `xml:"type,attr"`
+					} `xml:"content"`
+				} `xml:"entry"`
+			}
+			if err := xml.Unmarshal(w.Body.Bytes(), &feed); err != nil {
+				t.Fatalf("invalid Atom: %v", err)
+			}
+
+			if feed.Title != "Test Site" || feed.ID != "https://example.com/" {
+				t.Errorf("feed title, id = %q, %q", feed.Title, feed.ID)
+			}
+			if feed.Updated != "2024-02-20T09:30:00Z" {
+				t.Errorf("updated = %q, want the newest post's date", feed.Updated)
+			}
+			if len(feed.Author) != 1 || feed.Author[0] != "Test Site" {
+				t.Errorf("feed author = %q, want the site title", feed.Author)
+			}
+			wantLinks := []link{{"https://example.com/", ""}, {"https://example.com" + tt.path, "self"}}
+			if len(feed.Links) != 2 || feed.Links[0] != wantLinks[0] || feed.Links[1] != wantLinks[1] {
+				t.Errorf("links = %+v, want %+v", feed.Links, wantLinks)
+			}
+
+			if len(feed.Entries) != 2 {
+				t.Fatalf("got %d entries, want 2", len(feed.Entries))
+			}
+			second, first := feed.Entries[0], feed.Entries[1]
+			if second.Title != "Second Post" || second.ID != "https://example.com/posts/second" || second.Link.Href != second.ID {
+				t.Errorf("first entry = %+v, want the newest post", second)
+			}
+			if len(second.Author) != 1 || second.Author[0] != "Jane Doe" {
+				t.Errorf("entry author = %q, want the frontmatter author", second.Author)
+			}
+			if len(first.Author) != 0 {
+				t.Errorf("entry without an author frontmatter has author %q, want the feed's", first.Author)
+			}
+			if first.Updated != "2024-01-10T00:00:00Z" || first.Summary != "The first one" || first.Content.Type != "html" {
+				t.Errorf("second entry = %+v", first)
+			}
+		})
+	}
+}